@@ -1,24 +1,34 @@
 package deploy
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/The-Pirateship/runtime/pkg/gcpConnector"
+	"github.com/The-Pirateship/runtime/pkg/provider"
 	"github.com/The-Pirateship/runtime/pkg/ssh"
 	"github.com/The-Pirateship/runtime/pkg/utils"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 func RegisterCommand(rootCmd *cobra.Command) {
 	deployCmd := &cobra.Command{
-		Use:   "deploy",
+		Use:   "deploy [service...]",
 		Short: "Deploy your project to the cloud",
+		Long:  "Deploy your project to the cloud. Pass service names or --profile to deploy a subset; their dependencies are included automatically.",
 		Run:   runDeploy,
 	}
 
+	deployCmd.Flags().StringP("profile", "p", "", "deploy only the services in this profile")
+	deployCmd.Flags().Bool("recreate", false, "replace instances that no longer match runtime.toml without asking")
+
 	rootCmd.AddCommand(deployCmd)
 }
 
@@ -26,105 +36,237 @@ func runDeploy(cmd *cobra.Command, args []string) {
 	ctx := context.Background()
 
 	// Parse config
-	parsedConfig := utils.ParseConfig("runtime.toml")
+	parsedConfig, err := utils.ParseConfig("runtime.toml")
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
 	if len(parsedConfig.Services) == 0 {
 		fmt.Println("❌ No services found in runtime.toml")
-		return
+		os.Exit(1)
+	}
+
+	// Open the ports of every service, not only the ones being deployed, since the
+	// firewall rule is shared by the whole project
+	declaredPorts := parsedConfig.DeclaredPorts()
+
+	// Narrow down to the requested services and profile, pulling in dependencies
+	profile, _ := cmd.Flags().GetString("profile")
+	parsedConfig, err = parsedConfig.Select(profile, args)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
 	}
 
-	// Validate services
+	recreate, _ := cmd.Flags().GetBool("recreate")
+
+	// Find the deploy target for each service before touching anything
+	providers := map[string]provider.Provider{}
+	var targets []provider.Provider
 	for _, service := range parsedConfig.Services {
 		if service.RunsOn == "" {
 			fmt.Printf("❌ Service '%s' is missing required 'runsOn' field for deployment\n", service.Name)
 			fmt.Println("   Add 'runsOn = \"gcp.e2-micro\"' to each service in runtime.toml")
-			return
+			os.Exit(1)
 		}
 
-		if service.RunsOn != "gcp.e2-micro" {
-			fmt.Printf("❌ Invalid runsOn value '%s' for service '%s'. Only 'gcp.e2-micro' is supported\n", service.RunsOn, service.Name)
-			return
+		target, err := provider.ForRunsOn(service.RunsOn)
+		if err != nil {
+			fmt.Printf("❌ Invalid runsOn for service '%s': %v\n", service.Name, err)
+			os.Exit(1)
 		}
-	}
-
-	fmt.Printf("🚀 Deploying %d service(s) to GCP...\n\n", len(parsedConfig.Services))
 
-	// Validate project
-	if err := gcpConnector.ValidateProject(ctx, parsedConfig.Name); err != nil {
-		fmt.Printf("❌ %v\n", err)
-		return
+		if !containsProvider(targets, target) {
+			targets = append(targets, target)
+		}
+		providers[service.Name] = target
 	}
 
+	fmt.Printf("🚀 Deploying %d service(s) to %s...\n\n", len(parsedConfig.Services), describeProviders(targets))
+
 	// Setup SSH keys
-	fmt.Println("\n🔑 Setting up SSH access...")
+	fmt.Println("🔑 Setting up SSH access...")
 	sshPublicKey, err := ssh.GetOrCreateSSHKey()
 	if err != nil {
 		fmt.Printf("❌ Failed to setup SSH: %v\n", err)
-		return
+		os.Exit(1)
 	}
 
-	// Get compute service
-	fmt.Println("🔐 Authenticating with GCP...")
-	computeService, err := gcpConnector.GetComputeService(ctx)
-	if err != nil {
-		fmt.Printf("❌ %v\n", err)
-		return
-	}
-	fmt.Println("✅ Authenticated successfully\n")
+	for _, target := range targets {
+		if err := target.Validate(ctx, parsedConfig); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
 
-	// Setup firewall rules
-	if err := gcpConnector.EnsureFirewallRules(ctx, computeService, parsedConfig.Name); err != nil {
-		fmt.Printf("❌ Failed to setup firewall: %v\n", err)
-		return
+		if err := target.EnsureNetwork(ctx, parsedConfig, declaredPorts); err != nil {
+			fmt.Printf("❌ Failed to setup firewall: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	// Deploy each service
-	zone := "us-central1-a"
-
-	for _, service := range parsedConfig.Services {
+	// Deploy dependencies before the services that need them
+	for _, service := range utils.StartOrder(parsedConfig.Services) {
 		fmt.Printf("📦 Deploying service: %s\n", service.Name)
 
-		// Create instance
-		instanceName := fmt.Sprintf("runtime-%s-%s", parsedConfig.Name, service.Name)
-		instance, err := gcpConnector.CreateInstance(ctx, computeService, gcpConnector.InstanceConfig{
-			Name:      instanceName,
-			Zone:      zone,
-			ProjectID: parsedConfig.Name,
-			SSHKey:    sshPublicKey,
-		})
+		instance, err := ensureInstance(ctx, providers[service.Name], parsedConfig, service, sshPublicKey, recreate)
 		if err != nil {
-			fmt.Printf("❌ Failed to create instance: %v\n", err)
-			return
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
 		}
 
-		externalIP := gcpConnector.GetExternalIP(instance)
-		fmt.Printf("   🌐 Instance IP: %s\n", externalIP)
+		if err := deployToInstance(ctx, parsedConfig, service, instance); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("   ✅ %s deployed to instance\n\n", service.Name)
+	}
+
+	fmt.Println("🎉 All services deployed successfully!")
+}
 
-		// Setup SSH client
-		sshClient := &ssh.Client{
-			Host: externalIP,
-			User: "runtime",
+// ensureInstance returns a running instance for the service: the existing one when it
+// still matches runtime.toml, started if it was stopped, or else a new one. An instance
+// that no longer matches is only replaced with recreate set or the user's go-ahead.
+func ensureInstance(ctx context.Context, target provider.Provider, config utils.Config, service utils.Service, sshKey string, recreate bool) (provider.Instance, error) {
+	instance, err := target.GetInstance(ctx, config, service)
+	switch {
+	case errors.Is(err, provider.ErrInstanceNotFound):
+		instance, err = target.CreateInstance(ctx, config, service, sshKey)
+		if err != nil {
+			return provider.Instance{}, fmt.Errorf("failed to create instance: %w", err)
 		}
+		return instance, nil
 
-		// Wait for SSH to be ready
-		if err := sshClient.WaitForSSH(2 * time.Minute); err != nil {
-			fmt.Printf("❌ %v\n", err)
-			return
+	case err != nil:
+		return provider.Instance{}, err
+
+	case len(instance.Changes) > 0:
+		changes := strings.Join(instance.Changes, ", ")
+		if !recreate && !confirmReplace(instance.Name, changes) {
+			return provider.Instance{}, fmt.Errorf("instance %s no longer matches runtime.toml (%s); run 'runtime deploy --recreate' to replace it", instance.Name, changes)
 		}
 
-		// Upload code
-		absPath, err := filepath.Abs(service.Path)
+		fmt.Printf("   ♻️  Replacing instance %s: %s\n", instance.Name, changes)
+		if err := target.DeleteInstance(ctx, config, service); err != nil {
+			return provider.Instance{}, fmt.Errorf("failed to delete instance: %w", err)
+		}
+		instance, err = target.CreateInstance(ctx, config, service, sshKey)
 		if err != nil {
-			fmt.Printf("❌ Failed to resolve path: %v\n", err)
-			return
+			return provider.Instance{}, fmt.Errorf("failed to create instance: %w", err)
 		}
+		return instance, nil
 
-		if err := sshClient.UploadDirectory(absPath, "/home/runtime/app"); err != nil {
-			fmt.Printf("❌ Failed to upload code: %v\n", err)
-			return
+	case instance.Stopped:
+		instance, err = target.StartInstance(ctx, config, service)
+		if err != nil {
+			return provider.Instance{}, fmt.Errorf("failed to start instance: %w", err)
 		}
+		return instance, nil
 
-		fmt.Printf("   ✅ %s deployed to instance\n\n", service.Name)
+	default:
+		fmt.Printf("   ♻️  Reusing instance %s\n", instance.Name)
+		return instance, nil
 	}
+}
 
-	fmt.Println("🎉 All services deployed successfully!")
+// deployToInstance uploads a service's code and environment to its instance and
+// starts it there, waiting for its health check if it has one
+func deployToInstance(ctx context.Context, config utils.Config, service utils.Service, instance provider.Instance) error {
+	fmt.Printf("   🌐 Instance address: %s\n", instance.Address)
+
+	sshClient := &ssh.Client{
+		Host: instance.Address,
+		User: instance.User,
+		Port: instance.Port,
+
+		SkipHostKeyCheck: instance.Disposable,
+	}
+
+	// Wait for SSH to be ready
+	if err := sshClient.WaitForSSH(2 * time.Minute); err != nil {
+		return err
+	}
+
+	user, home, err := remoteAccount(sshClient)
+	if err != nil {
+		return err
+	}
+	remoteAppDir := appDir(home, config, service)
+
+	// Upload code
+	absPath, err := filepath.Abs(service.Path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	if err := sshClient.UploadDirectory(absPath, remoteAppDir); err != nil {
+		return fmt.Errorf("failed to upload code: %w", err)
+	}
+
+	// Ship the resolved environment, since .env files are usually not committed
+	env, err := config.ResolveEnv(service)
+	if err != nil {
+		return fmt.Errorf("failed to resolve environment: %w", err)
+	}
+	if _, ok := env["PORT"]; service.AutoPort && !ok {
+		env["PORT"] = strconv.Itoa(utils.DeployedAutoPort)
+	}
+
+	envPath := remoteAppDir + "/" + utils.DeployedEnvFile
+	if err := sshClient.WriteFile(envPath, utils.FormatEnvFile(env), 0600); err != nil {
+		return fmt.Errorf("failed to upload environment: %w", err)
+	}
+	fmt.Printf("   🔐 Uploaded %d environment variable(s)\n", len(env))
+
+	// Run the service under systemd
+	if err := startService(sshClient, config, service, user, remoteAppDir); err != nil {
+		return err
+	}
+	fmt.Printf("   ▶️  Started %s\n", unitName(config, service))
+
+	// Only report success once the service answers its health check
+	if service.HealthCheck != nil {
+		if err := verifyHealth(ctx, sshClient, config, service, remoteAppDir); err != nil {
+			return fmt.Errorf("%s failed its health check: %w", service.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// confirmReplace asks whether to replace an instance, which deletes it along with its
+// disk. Without a terminal to ask on, the answer is no.
+func confirmReplace(name, changes string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+
+	fmt.Printf("   ⚠️  Instance %s no longer matches runtime.toml: %s\n", name, changes)
+	fmt.Print("   Replace it? This deletes the instance and everything on its disk [y/N] ")
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+func containsProvider(providers []provider.Provider, target provider.Provider) bool {
+	for _, p := range providers {
+		if p == target {
+			return true
+		}
+	}
+	return false
+}
+
+// describeProviders lists provider names for messages, like "GCP and SSH"
+func describeProviders(providers []provider.Provider) string {
+	names := make([]string, len(providers))
+	for i, p := range providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, " and ")
 }
@@ -0,0 +1,160 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/health"
+	"github.com/The-Pirateship/runtime/pkg/ssh"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// appDir is where a service's code is uploaded, under the SSH user's home. Services
+// of several projects can share a server, so each gets its own directory.
+func appDir(home string, config utils.Config, service utils.Service) string {
+	return path.Join(home, "apps", config.Name, service.Name)
+}
+
+// unitName is the systemd unit that runs a service on its instance
+func unitName(config utils.Config, service utils.Service) string {
+	return fmt.Sprintf("runtime-%s-%s.service", config.Name, service.Name)
+}
+
+// remoteAccount returns the user runtime logs in as and their home directory. Both
+// come from the server, since the user may be left to ~/.ssh/config.
+func remoteAccount(client *ssh.Client) (string, string, error) {
+	output, err := client.Output("id -un && echo $HOME")
+	if err != nil {
+		return "", "", err
+	}
+
+	lines := strings.Fields(output)
+	if len(lines) != 2 {
+		return "", "", fmt.Errorf("unexpected reply when looking up the remote user: %q", output)
+	}
+	return lines[0], lines[1], nil
+}
+
+// systemdUnit renders the unit file that keeps a service running on the instance
+func systemdUnit(service utils.Service, user, appDir string) string {
+	// systemd expands $VAR and %specifiers in ExecStart, so escape them to keep the command
+	// literal. A newline would end the line, so a multi-line command keeps its newlines
+	// as \n, which systemd turns back into newlines inside the quotes.
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$", "%", "%%", "\n", `\n`, "\r", `\r`)
+
+	var unit strings.Builder
+	unit.WriteString("[Unit]\n")
+	unit.WriteString(fmt.Sprintf("Description=runtime service %s\n", service.Name))
+	unit.WriteString("After=network-online.target\n")
+	unit.WriteString("Wants=network-online.target\n")
+	unit.WriteString(restartLimit(service.Restart))
+	unit.WriteString("\n")
+	unit.WriteString("[Service]\n")
+	unit.WriteString(fmt.Sprintf("User=%s\n", user))
+	unit.WriteString(fmt.Sprintf("WorkingDirectory=%s\n", appDir))
+	unit.WriteString(fmt.Sprintf("EnvironmentFile=-%s/%s\n", appDir, utils.DeployedEnvFile))
+	unit.WriteString(fmt.Sprintf("ExecStart=/bin/sh -c \"%s\"\n", escaper.Replace(service.Command)))
+	unit.WriteString(restartSettings(service.Restart))
+	unit.WriteString("\n")
+	unit.WriteString("[Install]\n")
+	unit.WriteString("WantedBy=multi-user.target\n")
+
+	return unit.String()
+}
+
+// restartSettings translates a restart policy into [Service] settings. RestartSteps
+// and RestartMaxDelaySec give the same exponential backoff as runtime dev on
+// systemd 254 and later; older versions ignore them and use a fixed delay.
+func restartSettings(policy utils.RestartPolicy) string {
+	if policy.Policy == utils.RestartNo {
+		return "Restart=no\n"
+	}
+
+	var settings strings.Builder
+	settings.WriteString(fmt.Sprintf("Restart=%s\n", policy.Policy))
+	settings.WriteString(fmt.Sprintf("RestartSec=%s\n", systemdDuration(policy.Backoff)))
+
+	// Count the doublings it takes to get from backoff to maxBackoff
+	steps := 0
+	for delay := policy.Backoff; delay < policy.MaxBackoff; delay *= 2 {
+		steps++
+	}
+	if steps > 0 {
+		settings.WriteString(fmt.Sprintf("RestartSteps=%d\n", steps))
+		settings.WriteString(fmt.Sprintf("RestartMaxDelaySec=%s\n", systemdDuration(policy.MaxBackoff)))
+	}
+
+	return settings.String()
+}
+
+// restartLimit translates maxRetries into [Unit] start rate limiting: systemd gives up
+// once the service has been started more than maxRetries times within the window the
+// retries would take
+func restartLimit(policy utils.RestartPolicy) string {
+	if policy.Policy == utils.RestartNo {
+		return ""
+	}
+	if policy.MaxRetries == 0 {
+		return "StartLimitIntervalSec=0\n"
+	}
+
+	var window time.Duration
+	for attempt := 1; attempt <= policy.MaxRetries; attempt++ {
+		window += policy.Delay(attempt)
+	}
+	// Leave room for the time the service runs before each crash
+	window += time.Minute
+
+	return fmt.Sprintf("StartLimitIntervalSec=%s\nStartLimitBurst=%d\n", systemdDuration(window), policy.MaxRetries+1)
+}
+
+// systemdDuration formats a duration as a systemd time span, in milliseconds so nothing is rounded away
+func systemdDuration(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// startService installs the service's systemd unit and (re)starts it
+func startService(client *ssh.Client, config utils.Config, service utils.Service, user, appDir string) error {
+	unit := unitName(config, service)
+	tmpPath := "/tmp/" + unit
+
+	if err := client.WriteFile(tmpPath, []byte(systemdUnit(service, user, appDir)), 0644); err != nil {
+		return err
+	}
+
+	install := fmt.Sprintf("sudo mv %s /etc/systemd/system/%s && sudo systemctl daemon-reload && sudo systemctl enable --quiet %s && sudo systemctl restart %s",
+		tmpPath, unit, unit, unit)
+	if err := client.RunCommandQuiet(install); err != nil {
+		return fmt.Errorf("failed to start %s: %w", unit, err)
+	}
+
+	return nil
+}
+
+// verifyHealth runs the service's health check on the instance until it passes
+func verifyHealth(ctx context.Context, client *ssh.Client, config utils.Config, service utils.Service, appDir string) error {
+	check := *service.HealthCheck
+	command := health.RemoteCommand(check, appDir)
+
+	fmt.Printf("   ⏳ Waiting for health check (%s)...", health.Describe(check))
+
+	err := health.Wait(ctx, check, func(ctx context.Context) error {
+		return client.RunCommandQuiet(command)
+	}, func(try int, err error) {
+		fmt.Printf("\r   ⏳ Waiting for health check (%s)... (attempt %d)", health.Describe(check), try)
+	})
+	if err != nil {
+		fmt.Println()
+
+		// Show the tail of the service log to explain the failure
+		fmt.Printf("   📜 Last log lines from %s:\n", unitName(config, service))
+		client.RunCommand(fmt.Sprintf("sudo journalctl -u %s -n 20 --no-pager", unitName(config, service)))
+		return err
+	}
+
+	fmt.Printf("\r   ✅ Health check passed (%s)                    \n", health.Describe(check))
+	return nil
+}
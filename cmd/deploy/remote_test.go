@@ -0,0 +1,123 @@
+package deploy
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// TestSystemdUnitKeepsCommandLiteral undoes the ExecStart quoting the way systemd does
+// and runs the result with sh, checking $, %, quotes and newlines survive
+func TestSystemdUnitKeepsCommandLiteral(t *testing.T) {
+	command := "echo 'costs $5, 100%' \"\\\"quoted\\\"\" \\\\\necho second line"
+	unit := systemdUnit(utils.Service{Name: "api", Command: command}, "deploy", "/home/deploy/apps/shop/api")
+
+	var execStart string
+	for _, line := range strings.Split(unit, "\n") {
+		if strings.HasPrefix(line, "ExecStart=") {
+			execStart = line
+		}
+	}
+	const prefix = `ExecStart=/bin/sh -c "`
+	if !strings.HasPrefix(execStart, prefix) || !strings.HasSuffix(execStart, `"`) {
+		t.Fatalf("ExecStart should be a single line quoting the command, got unit:\n%s", unit)
+	}
+
+	argument, err := systemdUnquote(strings.TrimSuffix(strings.TrimPrefix(execStart, prefix), `"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if argument != command {
+		t.Fatalf("systemd would run %q, want %q", argument, command)
+	}
+
+	output, err := exec.Command("sh", "-c", argument).Output()
+	if err != nil {
+		t.Fatalf("failed to run the command: %v", err)
+	}
+	if want := "costs $5, 100% \"quoted\" \\\nsecond line\n"; string(output) != want {
+		t.Errorf("command printed %q, want %q", output, want)
+	}
+}
+
+// systemdUnquote decodes the inside of a double-quoted systemd argument: C escapes,
+// $$ for $ and %% for %. An unescaped quote or lone $ or % would have been expanded
+// or ended the argument.
+func systemdUnquote(quoted string) (string, error) {
+	escapes := map[byte]byte{'n': '\n', 'r': '\r', 't': '\t', '\\': '\\', '"': '"', '\'': '\''}
+
+	var result strings.Builder
+	for i := 0; i < len(quoted); i++ {
+		switch c := quoted[i]; c {
+		case '\\':
+			if i+1 == len(quoted) || escapes[quoted[i+1]] == 0 {
+				return "", fmt.Errorf("unescaped %q at offset %d of %s", quoted[i], i, quoted)
+			}
+			i++
+			result.WriteByte(escapes[quoted[i]])
+		case '$', '%':
+			if i+1 == len(quoted) || quoted[i+1] != c {
+				return "", fmt.Errorf("unescaped %q at offset %d of %s", quoted[i], i, quoted)
+			}
+			i++
+			result.WriteByte(c)
+		case '"':
+			return "", fmt.Errorf("unescaped %q at offset %d of %s", quoted[i], i, quoted)
+		default:
+			result.WriteByte(c)
+		}
+	}
+	return result.String(), nil
+}
+
+// TestRestartPolicyToSystemd checks restart policies become the same backoff and
+// retry limit under systemd as under runtime dev
+func TestRestartPolicyToSystemd(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   utils.RestartPolicy
+		settings string
+		limit    string
+	}{
+		{
+			name:     "no",
+			policy:   utils.RestartPolicy{Policy: utils.RestartNo, MaxRetries: 5, Backoff: time.Second, MaxBackoff: 30 * time.Second},
+			settings: "Restart=no\n",
+			limit:    "",
+		},
+		{
+			name:     "defaults",
+			policy:   utils.RestartPolicy{Policy: utils.RestartOnFailure, MaxRetries: 5, Backoff: time.Second, MaxBackoff: 30 * time.Second},
+			settings: "Restart=on-failure\nRestartSec=1000ms\nRestartSteps=5\nRestartMaxDelaySec=30000ms\n",
+			// 1s + 2s + 4s + 8s + 16s of delays plus a minute, and the first start counts too
+			limit: "StartLimitIntervalSec=91000ms\nStartLimitBurst=6\n",
+		},
+		{
+			name:     "capped delays",
+			policy:   utils.RestartPolicy{Policy: utils.RestartAlways, MaxRetries: 3, Backoff: 500 * time.Millisecond, MaxBackoff: time.Second},
+			settings: "Restart=always\nRestartSec=500ms\nRestartSteps=1\nRestartMaxDelaySec=1000ms\n",
+			limit:    "StartLimitIntervalSec=62500ms\nStartLimitBurst=4\n",
+		},
+		{
+			name:     "fixed delay without limit",
+			policy:   utils.RestartPolicy{Policy: utils.RestartAlways, MaxRetries: 0, Backoff: 2 * time.Second, MaxBackoff: 2 * time.Second},
+			settings: "Restart=always\nRestartSec=2000ms\n",
+			limit:    "StartLimitIntervalSec=0\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := restartSettings(tc.policy); got != tc.settings {
+				t.Errorf("restartSettings gave\n%s\nwant\n%s", got, tc.settings)
+			}
+			if got := restartLimit(tc.policy); got != tc.limit {
+				t.Errorf("restartLimit gave\n%s\nwant\n%s", got, tc.limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package dev
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// registerAttachCommand adds runtime attach, which joins the project's running session
+// from another terminal
+func registerAttachCommand(rootCmd *cobra.Command) {
+	attachCmd := &cobra.Command{
+		Use:   "attach",
+		Short: "Attach to the project's running dev session",
+		Args:  cobra.NoArgs,
+		Run:   runAttach,
+	}
+
+	attachCmd.Flags().String("multiplexer", "", "multiplexer the session runs in: \"zellij\" or \"tmux\" (default: look in both)")
+	rootCmd.AddCommand(attachCmd)
+}
+
+func runAttach(cmd *cobra.Command, args []string) {
+	parsedConfig, err := utils.ParseConfig("runtime.toml")
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	session := sessionName(parsedConfig)
+
+	// The session may be in either multiplexer unless one was asked for
+	candidates := multiplexers
+	multiplexerFlag, _ := cmd.Flags().GetString("multiplexer")
+	if multiplexerFlag != "" {
+		mux, err := selectMultiplexer(multiplexerFlag, "")
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		candidates = []multiplexer{mux}
+	}
+
+	for _, mux := range candidates {
+		if !isInstalled(mux) || !mux.SessionExists(session) {
+			continue
+		}
+
+		fmt.Printf("🔗 Attaching to %s session '%s'\n", mux.Name(), session)
+		if err := mux.Attach(session); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if running, ok := runningService(parsedConfig); ok && running.Multiplexer == "" {
+		fmt.Printf("❌ This project is running in plain mode (pid %d), which can't be attached to\n", running.SupervisorPID)
+		os.Exit(1)
+	}
+
+	fmt.Printf("❌ No running session '%s'. Start one with: runtime dev\n", session)
+	os.Exit(1)
+}
@@ -3,63 +3,218 @@ package dev
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 
+	"github.com/The-Pirateship/runtime/pkg/supervisor"
 	"github.com/The-Pirateship/runtime/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
 func RegisterCommand(rootCmd *cobra.Command) {
 	runCmd := &cobra.Command{
-		Use:   "dev",
+		Use:   "dev [service...]",
 		Short: "Run your project locally",
+		Long:  "Run your project locally. Pass service names or --profile to run a subset; their dependencies are included automatically.",
 		Run:   runDev,
 	}
 
+	runCmd.Flags().StringP("profile", "p", "", "run only the services in this profile")
+	runCmd.Flags().String("mode", "multiplexer", "how to run services: \"multiplexer\" (a tab per service) or \"plain\" (child processes with prefixed output)")
+	runCmd.Flags().String("multiplexer", "", "multiplexer to use: \"zellij\" or \"tmux\" (default: runtime.toml, then whichever is installed)")
+	runCmd.Flags().Bool("fresh", false, "end the project's running session and start a new one instead of reattaching")
+	runCmd.Flags().Duration("grace-period", supervisor.DefaultGracePeriod, "how long services get to exit after Ctrl-C in plain mode")
+
 	rootCmd.AddCommand(runCmd)
+	registerSuperviseCommand(rootCmd)
+	registerAttachCommand(rootCmd)
+	registerProxyCommand(rootCmd)
+}
+
+// superviseArgs returns the command line a pane runs for a service: this binary's
+// hidden supervise command, which waits for dependencies and sets up the environment
+func superviseArgs(config utils.Config, service utils.Service, multiplexer string) []string {
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "runtime"
+	}
+
+	return []string{executable, "supervise", "--config", filepath.Join(config.Dir, "runtime.toml"), "--multiplexer", multiplexer, service.Name}
+}
+
+// statusPaneArgs returns the command line for the status tab shown when services have health checks
+func statusPaneArgs(config utils.Config) []string {
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "runtime"
+	}
+
+	args := []string{executable, "status", "--watch", "--config", filepath.Join(config.Dir, "runtime.toml")}
+	return append(args, config.ServiceNames()...)
+}
+
+// hasHealthChecks reports whether any service defines a health check
+func hasHealthChecks(config utils.Config) bool {
+	for _, service := range config.Services {
+		if service.HealthCheck != nil {
+			return true
+		}
+	}
+	return false
 }
 
 func runDev(cmd *cobra.Command, args []string) {
 	// Parse config using shared utils
-	parsedConfig := utils.ParseConfig("runtime.toml")
+	parsedConfig, err := utils.ParseConfig("runtime.toml")
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
 	if len(parsedConfig.Services) == 0 {
 		fmt.Println("❌ No services found in runtime.toml")
-		return
+		os.Exit(1)
 	}
 
-	// Generate Zellij layout
-	if err := generateZellijLayout(parsedConfig); err != nil {
-		fmt.Printf("❌ Failed to generate Zellij layout: %v\n", err)
-		return
+	// Look for a session started earlier before narrowing down the services
+	running, isRunning := runningService(parsedConfig)
+	sessionServices := supervisedServices(parsedConfig)
+
+	// Narrow down to the requested services and profile, pulling in dependencies
+	profile, _ := cmd.Flags().GetString("profile")
+	parsedConfig, err = parsedConfig.Select(profile, args)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
 	}
 
-	// Generate Zellij config
-	if err := generateZellijConfig(); err != nil {
-		fmt.Printf("❌ Failed to generate Zellij config: %v\n", err)
+	mode, _ := cmd.Flags().GetString("mode")
+	switch mode {
+	case "plain":
+		if isRunning {
+			fmt.Printf("❌ This project is already running %s. Stop it first with: runtime stop\n", describeSession(running))
+			os.Exit(1)
+		}
+		clearStates(parsedConfig)
+		preparePorts(parsedConfig)
+
+		gracePeriod, _ := cmd.Flags().GetDuration("grace-period")
+		runPlain(parsedConfig, gracePeriod)
 		return
+	case "multiplexer":
+	default:
+		fmt.Printf("❌ Unknown mode '%s', expected \"multiplexer\" or \"plain\"\n", mode)
+		os.Exit(1)
 	}
 
-	// Check if Zellij is installed
-	if _, err := exec.LookPath("zellij"); err != nil {
-		fmt.Println("❌ Zellij not found. Please install Zellij first:")
-		fmt.Println("   brew install zellij")
-		fmt.Println("   or visit: https://zellij.dev/documentation/installation")
-		return
+	multiplexerFlag, _ := cmd.Flags().GetString("multiplexer")
+	mux, err := selectMultiplexer(multiplexerFlag, parsedConfig.Multiplexer)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
 	}
 
-	// Launch Zellij with the generated config and layout
-	configPath := filepath.Join(".zellij", "config.kdl")
-	layoutPath := filepath.Join(".zellij", "layout.kdl")
-	fmt.Printf("🚀 Launching Zellij with config: %s and layout: %s\n", configPath, layoutPath)
+	session := sessionName(parsedConfig)
+	fresh, _ := cmd.Flags().GetBool("fresh")
 
-	zellijCmd := exec.Command("zellij", "--config", configPath, "--layout", layoutPath)
-	zellijCmd.Stdin = os.Stdin
-	zellijCmd.Stdout = os.Stdout
-	zellijCmd.Stderr = os.Stderr
+	if mux.SessionExists(session) {
+		if !fresh {
+			// Reattaching keeps the session's services, whatever was asked for this time
+			selected := parsedConfig.ServiceNames()
+			if (profile != "" || len(args) > 0) && !sameNames(sessionServices, selected) {
+				fmt.Printf("⚠️  The running session keeps the services it was started with (%s) rather than the ones you asked for (%s)\n",
+					describeNames(sessionServices), strings.Join(selected, ", "))
+				fmt.Println("   Use --fresh to end it and start one with yours")
+			}
 
-	if err := zellijCmd.Run(); err != nil {
-		fmt.Printf("❌ Failed to run Zellij: %v\n", err)
-		return
+			fmt.Printf("🔗 Attaching to running session '%s' (use --fresh to start a new one)\n", session)
+			if err := mux.Attach(session); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		fmt.Printf("♻️  Ending session '%s' to start a fresh one\n", session)
+		if err := mux.KillSession(session); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	} else if isRunning {
+		fmt.Printf("❌ This project is already running %s. Stop it first with: runtime stop\n", describeSession(running))
+		os.Exit(1)
+	}
+
+	clearStates(parsedConfig)
+	preparePorts(parsedConfig)
+	if err := mux.Launch(parsedConfig, session); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// clearStates starts from a clean slate so panes don't see services from a previous session as running
+func clearStates(config utils.Config) {
+	if err := supervisor.ClearStates(supervisor.StateDir(config.Dir)); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runningService returns the state of a service that a live supervisor is still
+// running, which means the project already has a dev session
+func runningService(config utils.Config) (supervisor.State, bool) {
+	stateDir := supervisor.StateDir(config.Dir)
+	for _, service := range config.Services {
+		state, err := supervisor.ReadState(stateDir, service.Name)
+		if err == nil && state.Supervised() {
+			return state, true
+		}
+	}
+	return supervisor.State{}, false
+}
+
+// supervisedServices returns the names of the services a live supervisor is running
+func supervisedServices(config utils.Config) []string {
+	stateDir := supervisor.StateDir(config.Dir)
+	var names []string
+	for _, service := range config.Services {
+		state, err := supervisor.ReadState(stateDir, service.Name)
+		if err == nil && state.Supervised() {
+			names = append(names, service.Name)
+		}
+	}
+	return names
+}
+
+// sameNames reports whether a and b hold the same names, in any order
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := map[string]bool{}
+	for _, name := range a {
+		seen[name] = true
+	}
+	for _, name := range b {
+		if !seen[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// describeNames lists service names for messages, or says they aren't known
+func describeNames(names []string) string {
+	if len(names) == 0 {
+		return "unknown"
+	}
+	return strings.Join(names, ", ")
+}
+
+// describeSession explains where a running service lives, for error messages
+func describeSession(state supervisor.State) string {
+	if state.Multiplexer == "" {
+		return fmt.Sprintf("in plain mode (pid %d)", state.SupervisorPID)
 	}
+	return fmt.Sprintf("in %s session '%s'", state.Multiplexer, state.Session)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/The-Pirateship/runtime/pkg/utils"
@@ -16,36 +17,24 @@ func generateZellijLayout(config utils.Config) error {
 		return fmt.Errorf("failed to create .zellij directory: %w", err)
 	}
 
-	// Generate KDL layout content
-	var layoutBuilder strings.Builder
-	layoutBuilder.WriteString("layout {\n")
-
-	// Add default tab template with tab-bar and status-bar
-	layoutBuilder.WriteString("    default_tab_template {\n")
-	layoutBuilder.WriteString("        pane size=1 borderless=true {\n")
-	layoutBuilder.WriteString("            plugin location=\"zellij:tab-bar\"\n")
-	layoutBuilder.WriteString("        }\n")
-	layoutBuilder.WriteString("        children\n")
-	// removed cuz we dont wnat the bottom status bar
-	// layoutBuilder.WriteString("        pane size=2 borderless=true {\n")
-	// layoutBuilder.WriteString("            plugin location=\"zellij:status-bar\"\n")
-	// layoutBuilder.WriteString("        }\n")
-	layoutBuilder.WriteString("    }\n")
-
-	// Add tabs for each service
-	for _, service := range config.Services {
-		layoutBuilder.WriteString(fmt.Sprintf("    tab name=\"%s\" {\n", service.Name))
-		layoutBuilder.WriteString(fmt.Sprintf("        pane borderless=true command=\"sh\" cwd=\"%s\" {\n", service.Path))
-		layoutBuilder.WriteString(fmt.Sprintf("            args \"-c\" \"%s\"\n", service.Command))
-		layoutBuilder.WriteString("        }\n")
-		layoutBuilder.WriteString("    }\n")
-	}
+	// Default tab template with just the tab-bar; we don't want the bottom status bar
+	layout := newKDLNode("layout").child(
+		newKDLNode("default_tab_template").child(
+			newKDLNode("pane").prop("size", 1).prop("borderless", true).child(
+				newKDLNode("plugin").prop("location", "zellij:tab-bar"),
+			),
+			newKDLNode("children"),
+		),
+	)
 
-	layoutBuilder.WriteString("}\n")
+	// Add a tab per service, or per [layout.tabs] group
+	for _, t := range buildTabs(config, "zellij") {
+		layout.child(zellijTab(t))
+	}
 
 	// Write layout file
 	layoutPath := filepath.Join(zellijDir, "layout.kdl")
-	if err := os.WriteFile(layoutPath, []byte(layoutBuilder.String()), 0644); err != nil {
+	if err := os.WriteFile(layoutPath, []byte(renderKDL(layout)), 0644); err != nil {
 		return fmt.Errorf("failed to write layout file: %w", err)
 	}
 
@@ -53,6 +42,59 @@ func generateZellijLayout(config utils.Config) error {
 	return nil
 }
 
+// zellijTab builds a tab node holding the tab's panes
+func zellijTab(t tab) *kdlNode {
+	node := newKDLNode("tab").prop("name", t.name)
+	if len(t.panes) > 1 {
+		node.prop("split_direction", t.split)
+	}
+
+	for _, p := range t.panes {
+		// Borders only help tell panes apart when there is more than one
+		node.child(zellijPane(p, len(t.panes) == 1))
+	}
+	return node
+}
+
+// zellijPane builds a pane node, running the pane's command if it has one or a shell otherwise
+func zellijPane(p pane, borderless bool) *kdlNode {
+	node := newKDLNode("pane").prop("name", p.name)
+	if borderless {
+		node.prop("borderless", true)
+	}
+	if p.size != "" {
+		if size, err := strconv.Atoi(p.size); err == nil {
+			node.prop("size", size)
+		} else {
+			node.prop("size", p.size)
+		}
+	}
+	if p.cwd != "" {
+		node.prop("cwd", p.cwd)
+	}
+	if len(p.args) == 0 {
+		return node
+	}
+
+	node.prop("command", p.args[0])
+
+	args := make([]interface{}, len(p.args)-1)
+	for i, arg := range p.args[1:] {
+		args[i] = kdlArg(arg)
+	}
+
+	return node.child(newKDLNode("args", args...))
+}
+
+// kdlArg keeps shell commands readable in the layout: ones full of quotes and
+// backslashes are written as raw strings instead of escaped ones
+func kdlArg(arg string) interface{} {
+	if strings.ContainsAny(arg, `"\`) {
+		return kdlRaw(arg)
+	}
+	return arg
+}
+
 func generateZellijConfig() error {
 	// Create .zellij directory if it doesn't exist
 	zellijDir := ".zellij"
@@ -61,19 +103,18 @@ func generateZellijConfig() error {
 	}
 
 	// Generate KDL config content with keybindings
-	var configBuilder strings.Builder
-	configBuilder.WriteString("keybinds {\n")
-	configBuilder.WriteString("    normal {\n")
-	configBuilder.WriteString("        bind \"Ctrl ,\" { GoToPreviousTab; }\n") // maps to the < carrot
-	configBuilder.WriteString("        bind \"Ctrl .\" { GoToNextTab; }\n")     // maps to the > carrot
-	configBuilder.WriteString("        bind \"Ctrl t\" { NewTab; }\n")
-	configBuilder.WriteString("        bind \"Ctrl q\" { Quit; }\n")
-	configBuilder.WriteString("    }\n")
-	configBuilder.WriteString("}\n")
+	config := newKDLNode("keybinds").child(
+		newKDLNode("normal").child(
+			newKDLNode("bind", "Ctrl ,").child(newKDLNode("GoToPreviousTab")), // maps to the < carrot
+			newKDLNode("bind", "Ctrl .").child(newKDLNode("GoToNextTab")),     // maps to the > carrot
+			newKDLNode("bind", "Ctrl t").child(newKDLNode("NewTab")),
+			newKDLNode("bind", "Ctrl q").child(newKDLNode("Quit")),
+		),
+	)
 
 	// Write config file
 	configPath := filepath.Join(zellijDir, "config.kdl")
-	if err := os.WriteFile(configPath, []byte(configBuilder.String()), 0644); err != nil {
+	if err := os.WriteFile(configPath, []byte(renderKDL(config)), 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
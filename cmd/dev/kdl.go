@@ -0,0 +1,174 @@
+package dev
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// kdlNode is a node in a KDL document: a name followed by arguments, properties
+// and an optional block of children. Values are escaped when the document is
+// rendered, so arbitrary service names, paths and commands are always safe.
+type kdlNode struct {
+	name     string
+	args     []interface{}
+	props    []kdlProp
+	children []*kdlNode
+}
+
+type kdlProp struct {
+	key   string
+	value interface{}
+}
+
+// kdlRaw is a string rendered as a KDL raw string (r#"..."#), which needs no escaping
+type kdlRaw string
+
+// newKDLNode creates a node with the given arguments. Supported values are
+// string, kdlRaw, bool and the integer types.
+func newKDLNode(name string, args ...interface{}) *kdlNode {
+	return &kdlNode{name: name, args: args}
+}
+
+// prop adds a key=value property, keeping the order properties were added in
+func (n *kdlNode) prop(key string, value interface{}) *kdlNode {
+	n.props = append(n.props, kdlProp{key: key, value: value})
+	return n
+}
+
+// child appends children to the node's block
+func (n *kdlNode) child(children ...*kdlNode) *kdlNode {
+	n.children = append(n.children, children...)
+	return n
+}
+
+// renderKDL renders top-level nodes as a KDL document indented with four spaces
+func renderKDL(nodes ...*kdlNode) string {
+	var builder strings.Builder
+	for _, node := range nodes {
+		node.write(&builder, 0)
+	}
+	return builder.String()
+}
+
+func (n *kdlNode) write(builder *strings.Builder, depth int) {
+	builder.WriteString(strings.Repeat("    ", depth))
+	builder.WriteString(kdlIdentifier(n.name))
+
+	for _, arg := range n.args {
+		builder.WriteString(" ")
+		builder.WriteString(kdlValue(arg))
+	}
+
+	for _, prop := range n.props {
+		builder.WriteString(" ")
+		builder.WriteString(kdlIdentifier(prop.key))
+		builder.WriteString("=")
+		builder.WriteString(kdlValue(prop.value))
+	}
+
+	if len(n.children) > 0 {
+		builder.WriteString(" {\n")
+		for _, child := range n.children {
+			child.write(builder, depth+1)
+		}
+		builder.WriteString(strings.Repeat("    ", depth))
+		builder.WriteString("}")
+	}
+
+	builder.WriteString("\n")
+}
+
+// kdlValue renders a value, panicking on types the builder doesn't support since
+// that can only be a programming error
+func kdlValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return kdlString(v)
+	case kdlRaw:
+		return kdlRawString(string(v))
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		panic(fmt.Sprintf("unsupported KDL value type %T", value))
+	}
+}
+
+// kdlString quotes a value as a KDL string, escaping quotes, backslashes and control characters
+func kdlString(value string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+
+	for _, r := range value {
+		switch r {
+		case '"':
+			builder.WriteString(`\"`)
+		case '\\':
+			builder.WriteString(`\\`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		case '\b':
+			builder.WriteString(`\b`)
+		case '\f':
+			builder.WriteString(`\f`)
+		default:
+			if unicode.IsControl(r) {
+				builder.WriteString(fmt.Sprintf(`\u{%x}`, r))
+			} else {
+				builder.WriteRune(r)
+			}
+		}
+	}
+
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+// kdlRawString renders a raw string, using enough # marks that the content can't end it early
+func kdlRawString(value string) string {
+	hashes := ""
+	for strings.Contains(value, `"`+hashes) {
+		hashes += "#"
+	}
+	return "r" + hashes + `"` + value + `"` + hashes
+}
+
+// kdlIdentifier renders a node name or property key bare when KDL allows it, quoted otherwise
+func kdlIdentifier(name string) string {
+	if isBareKDLIdentifier(name) {
+		return name
+	}
+	return kdlString(name)
+}
+
+func isBareKDLIdentifier(name string) bool {
+	if name == "" || name == "true" || name == "false" || name == "null" {
+		return false
+	}
+
+	for i, r := range name {
+		// Identifiers can't start with a digit, or they would be read as numbers
+		if i == 0 && unicode.IsDigit(r) {
+			return false
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.", r) {
+			return false
+		}
+	}
+
+	// A leading sign followed by a digit would also be read as a number
+	if len(name) > 1 && (name[0] == '-' || name[0] == '+') && unicode.IsDigit(rune(name[1])) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,382 @@
+package dev
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// kdlCases are documents with values that need escaping, each compared against
+// testdata/<name>.golden
+var kdlCases = []struct {
+	name     string
+	document []*kdlNode
+}{
+	{
+		name: "quotes",
+		document: []*kdlNode{zellijTab(tab{name: "api", panes: []pane{
+			{name: "api", cwd: "/srv/my \"quoted\" app", args: []string{"sh", "-c", `echo "hi"`}},
+		}})},
+	},
+	{
+		name: "backslashes",
+		document: []*kdlNode{zellijTab(tab{name: `C:\work`, panes: []pane{
+			{name: "worker", cwd: `C:\work\worker`, args: []string{"sh", "-c", `printf 'a\\b\n' | sed 's/\\/\//g'`}},
+		}})},
+	},
+	{
+		name: "newlines",
+		document: []*kdlNode{zellijTab(tab{name: "multi\nline", split: "vertical", panes: []pane{
+			{name: "first\tpane", args: []string{"sh", "-c", "echo one\necho two"}},
+			{name: "bell\x07", size: "40%"},
+		}})},
+	},
+	{
+		name: "raw_strings",
+		document: []*kdlNode{
+			newKDLNode("plain", kdlRaw(`no quotes at all`)),
+			newKDLNode("quote", kdlRaw(`say "hi"`)),
+			newKDLNode("hash", kdlRaw(`ends with "# and "## too`)),
+			newKDLNode("backslash", kdlRaw(`C:\path\n stays literal`)),
+		},
+	},
+	{
+		name: "identifiers",
+		document: []*kdlNode{
+			newKDLNode("bare-name_1.0").prop("key", 1),
+			newKDLNode("123").prop("9lives", true),
+			newKDLNode("true").prop("null", false),
+			newKDLNode("-1").prop("+2", int64(-3)),
+			newKDLNode("has space").prop("with\"quote", "v"),
+			newKDLNode("").prop("back\\slash", ""),
+			newKDLNode("-dash").prop("ünïcode", "ok"),
+		},
+	},
+}
+
+func TestKDLGolden(t *testing.T) {
+	for _, tc := range kdlCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rendered := renderKDL(tc.document...)
+			golden := filepath.Join("testdata", tc.name+".golden")
+
+			if *update {
+				if err := os.WriteFile(golden, []byte(rendered), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read %s (run go test -update to create it): %v", golden, err)
+			}
+			if rendered != string(want) {
+				t.Errorf("rendered KDL differs from %s\n--- got ---\n%s\n--- want ---\n%s", golden, rendered, want)
+			}
+		})
+	}
+}
+
+func TestKDLRoundTrip(t *testing.T) {
+	for _, tc := range kdlCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rendered := renderKDL(tc.document...)
+
+			parsed, err := parseKDL(rendered)
+			if err != nil {
+				t.Fatalf("rendered KDL doesn't parse: %v\n%s", err, rendered)
+			}
+
+			want := make([]*kdlNode, len(tc.document))
+			for i, node := range tc.document {
+				want[i] = normalizeKDL(node)
+			}
+			if !reflect.DeepEqual(parsed, want) {
+				t.Errorf("round trip changed the document\n--- rendered ---\n%s\n--- parsed ---\n%s", rendered, renderKDL(parsed...))
+			}
+		})
+	}
+}
+
+func TestKDLArg(t *testing.T) {
+	if _, raw := kdlArg("npm run dev").(kdlRaw); raw {
+		t.Error("plain arguments should stay regular strings")
+	}
+	for _, arg := range []string{`echo "hi"`, `a\b`} {
+		if _, raw := kdlArg(arg).(kdlRaw); !raw {
+			t.Errorf("%q should be written as a raw string", arg)
+		}
+	}
+}
+
+// normalizeKDL converts values to what parseKDL returns: strings for both string
+// kinds and int64 for every integer
+func normalizeKDL(node *kdlNode) *kdlNode {
+	normalized := &kdlNode{name: node.name}
+	for _, arg := range node.args {
+		normalized.args = append(normalized.args, normalizeKDLValue(arg))
+	}
+	for _, prop := range node.props {
+		normalized.props = append(normalized.props, kdlProp{key: prop.key, value: normalizeKDLValue(prop.value)})
+	}
+	for _, child := range node.children {
+		normalized.children = append(normalized.children, normalizeKDL(child))
+	}
+	return normalized
+}
+
+func normalizeKDLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case kdlRaw:
+		return string(v)
+	case int:
+		return int64(v)
+	default:
+		return v
+	}
+}
+
+// parseKDL reads back the subset of KDL renderKDL writes: nodes with string,
+// raw string, boolean and integer values, properties and child blocks
+func parseKDL(document string) ([]*kdlNode, error) {
+	p := &kdlParser{input: []rune(document)}
+	nodes, err := p.nodes()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected '%c'", p.input[p.pos])
+	}
+	return nodes, nil
+}
+
+type kdlParser struct {
+	input []rune
+	pos   int
+}
+
+func (p *kdlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *kdlParser) peek() rune {
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *kdlParser) skipSpaces() {
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.pos++
+	}
+}
+
+// nodes reads nodes until the end of the input or a closing brace
+func (p *kdlParser) nodes() ([]*kdlNode, error) {
+	var nodes []*kdlNode
+	for {
+		p.skipSpaces()
+		switch p.peek() {
+		case 0, '}':
+			return nodes, nil
+		case '\n':
+			p.pos++
+			continue
+		}
+
+		node, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+}
+
+func (p *kdlParser) node() (*kdlNode, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	node := &kdlNode{name: name}
+
+	for {
+		p.skipSpaces()
+		switch p.peek() {
+		case 0, '\n':
+			return node, nil
+
+		case '{':
+			p.pos++
+			if node.children, err = p.nodes(); err != nil {
+				return nil, err
+			}
+			if p.peek() != '}' {
+				return nil, p.errorf("unclosed block")
+			}
+			p.pos++
+			return node, nil
+		}
+
+		// A property is an identifier followed by '='; anything else is an argument
+		start := p.pos
+		if key, err := p.identifier(); err == nil && p.peek() == '=' {
+			p.pos++
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			node.props = append(node.props, kdlProp{key: key, value: value})
+			continue
+		}
+		p.pos = start
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		node.args = append(node.args, value)
+	}
+}
+
+// identifier reads a bare or quoted identifier
+func (p *kdlParser) identifier() (string, error) {
+	if p.peek() == '"' {
+		return p.quoted()
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.+", r) {
+			break
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected an identifier")
+	}
+	return string(p.input[start:p.pos]), nil
+}
+
+func (p *kdlParser) value() (interface{}, error) {
+	switch {
+	case p.peek() == '"':
+		return p.quoted()
+	case p.peek() == 'r':
+		return p.raw()
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune(" \t\n{}", p.input[p.pos]) {
+		p.pos++
+	}
+	word := string(p.input[start:p.pos])
+
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	n, err := strconv.ParseInt(word, 10, 64)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid value %q", word)
+	}
+	return n, nil
+}
+
+// quoted reads an escaped string
+func (p *kdlParser) quoted() (string, error) {
+	p.pos++ // opening quote
+
+	var builder strings.Builder
+	for {
+		if p.pos >= len(p.input) {
+			return "", p.errorf("unterminated string")
+		}
+		r := p.input[p.pos]
+		p.pos++
+
+		switch r {
+		case '"':
+			return builder.String(), nil
+		case '\n':
+			return "", p.errorf("unescaped newline in string")
+		case '\\':
+			escaped, err := p.escape()
+			if err != nil {
+				return "", err
+			}
+			builder.WriteRune(escaped)
+		default:
+			builder.WriteRune(r)
+		}
+	}
+}
+
+func (p *kdlParser) escape() (rune, error) {
+	r := p.peek()
+	p.pos++
+
+	switch r {
+	case '"', '\\', '/':
+		return r, nil
+	case 'n':
+		return '\n', nil
+	case 'r':
+		return '\r', nil
+	case 't':
+		return '\t', nil
+	case 'b':
+		return '\b', nil
+	case 'f':
+		return '\f', nil
+	case 'u':
+		end := strings.IndexRune(string(p.input[p.pos:]), '}')
+		if p.peek() != '{' || end < 0 {
+			return 0, p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseInt(string(p.input[p.pos+1:p.pos+end]), 16, 32)
+		if err != nil {
+			return 0, p.errorf("invalid unicode escape")
+		}
+		p.pos += end + 1
+		return rune(code), nil
+	default:
+		return 0, p.errorf("invalid escape '\\%c'", r)
+	}
+}
+
+// raw reads a raw string like r#"..."#, which ends at a quote followed by as many # as it started with
+func (p *kdlParser) raw() (string, error) {
+	p.pos++ // r
+
+	hashes := ""
+	for p.peek() == '#' {
+		hashes += "#"
+		p.pos++
+	}
+	if p.peek() != '"' {
+		return "", p.errorf("invalid raw string")
+	}
+	p.pos++
+
+	rest := string(p.input[p.pos:])
+	end := strings.Index(rest, `"`+hashes)
+	if end < 0 {
+		return "", p.errorf("unterminated raw string")
+	}
+	value := rest[:end]
+	p.pos += len([]rune(value)) + 1 + len(hashes)
+	return value, nil
+}
@@ -0,0 +1,112 @@
+package dev
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// multiplexer is a terminal multiplexer backend that can show each service in its own tab
+type multiplexer interface {
+	// Name is the value used for --multiplexer and the multiplexer key in runtime.toml
+	Name() string
+	// InstallHint explains how to install the multiplexer
+	InstallHint() []string
+	// Launch opens a named session with a tab per service and blocks until the user leaves it
+	Launch(config utils.Config, session string) error
+	// SessionExists reports whether a session with this name is running
+	SessionExists(session string) bool
+	// Attach joins a running session and blocks until the user leaves it
+	Attach(session string) error
+	// KillSession ends a running session and every pane in it
+	KillSession(session string) error
+	// CurrentSession returns the name of the session a pane is running in
+	CurrentSession() string
+}
+
+// multiplexers lists the supported backends; the first installed one wins auto-detection
+var multiplexers = []multiplexer{
+	zellijMultiplexer{},
+	tmuxMultiplexer{},
+}
+
+// invalidSessionChars matches characters multiplexers don't allow in session names
+var invalidSessionChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// sessionName is the multiplexer session a project runs in, so running runtime dev
+// again reattaches instead of starting every service a second time
+func sessionName(config utils.Config) string {
+	name := config.Name
+	if name == "" {
+		name = filepath.Base(config.Dir)
+	}
+
+	name = strings.Trim(invalidSessionChars.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		return "runtime"
+	}
+	return name
+}
+
+// selectMultiplexer picks the backend from the --multiplexer flag, then runtime.toml,
+// and otherwise whichever supported multiplexer is installed
+func selectMultiplexer(flagValue, configValue string) (multiplexer, error) {
+	requested := flagValue
+	if requested == "" {
+		requested = configValue
+	}
+
+	if requested != "" {
+		for _, mux := range multiplexers {
+			if mux.Name() == requested {
+				if !isInstalled(mux) {
+					return nil, fmt.Errorf("%s not found. Please install it first:\n   %s\n   or run without it: runtime dev --mode=plain",
+						mux.Name(), strings.Join(mux.InstallHint(), "\n   "))
+				}
+				return mux, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown multiplexer '%s', expected one of: %s", requested, strings.Join(multiplexerNames(), ", "))
+	}
+
+	for _, mux := range multiplexers {
+		if isInstalled(mux) {
+			return mux, nil
+		}
+	}
+
+	hints := []string{"No terminal multiplexer found. Please install one of:"}
+	for _, mux := range multiplexers {
+		hints = append(hints, mux.InstallHint()...)
+	}
+	hints = append(hints, "or run without one: runtime dev --mode=plain")
+	return nil, fmt.Errorf("%s", strings.Join(hints, "\n   "))
+}
+
+// isInstalled checks whether the multiplexer's binary is on PATH
+func isInstalled(mux multiplexer) bool {
+	_, err := exec.LookPath(mux.Name())
+	return err == nil
+}
+
+// findMultiplexer returns the backend with the given name
+func findMultiplexer(name string) (multiplexer, bool) {
+	for _, mux := range multiplexers {
+		if mux.Name() == name {
+			return mux, true
+		}
+	}
+	return nil, false
+}
+
+func multiplexerNames() []string {
+	names := make([]string, len(multiplexers))
+	for i, mux := range multiplexers {
+		names[i] = mux.Name()
+	}
+	return names
+}
@@ -0,0 +1,56 @@
+package dev
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/supervisor"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"golang.org/x/term"
+)
+
+// runPlain runs every service as a child process of runtime itself, Procfile style,
+// for machines without a terminal multiplexer (CI, containers)
+func runPlain(config utils.Config, gracePeriod time.Duration) {
+	fmt.Printf("🚀 Starting %d service(s) (Ctrl-C to stop)\n", len(config.Services))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	group := &supervisor.Group{
+		Config:      config,
+		Output:      os.Stdout,
+		Color:       term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("NO_COLOR") == "",
+		GracePeriod: gracePeriod,
+	}
+
+	// The first signal stops services gracefully, a second one kills them
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	go func() {
+		sig := <-signals
+		fmt.Printf("\n🛑 Received %v, stopping services (waiting up to %v, press Ctrl-C again to force)\n", sig, gracePeriod)
+		cancel()
+
+		<-signals
+		fmt.Println("💥 Killing services")
+		group.Kill()
+	}()
+
+	if config.Proxy != nil {
+		go func() {
+			if err := serveProxy(ctx, config); err != nil {
+				fmt.Printf("❌ %v\n", err)
+			}
+		}()
+	}
+
+	group.Run(ctx)
+	fmt.Println("👋 All services stopped")
+}
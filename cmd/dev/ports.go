@@ -0,0 +1,59 @@
+package dev
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/The-Pirateship/runtime/pkg/ports"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// preparePorts checks that every declared port is free before anything starts, so a
+// service doesn't fail halfway through startup, and picks ports for port = "auto" services
+func preparePorts(config utils.Config) {
+	declared := map[string][]int{}
+	for _, service := range config.Services {
+		if len(service.Ports) > 0 {
+			declared[service.Name] = service.Ports
+		}
+	}
+
+	if config.Proxy != nil {
+		declared["proxy"] = []int{config.Proxy.Port}
+	}
+
+	conflicts := ports.Check(declared)
+	if len(conflicts) > 0 {
+		sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Port < conflicts[j].Port })
+		for _, conflict := range conflicts {
+			holder := conflict.Holder
+			if holder == "" {
+				holder = "another process"
+			}
+			fmt.Printf("❌ Port %d for '%s' is already in use by %s\n", conflict.Port, conflict.Service, holder)
+		}
+		os.Exit(1)
+	}
+
+	// Every session gets new ports, since the ones picked last time may have been taken since
+	assigned := map[string]int{}
+	for _, service := range config.Services {
+		if !service.AutoPort {
+			continue
+		}
+
+		port, err := ports.Allocate()
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		assigned[service.Name] = port
+		fmt.Printf("🔌 %s will listen on port %d\n", service.Name, port)
+	}
+
+	if err := ports.Save(config.Dir, assigned); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,75 @@
+package dev
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/The-Pirateship/runtime/pkg/ports"
+	"github.com/The-Pirateship/runtime/pkg/proxy"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// registerProxyCommand adds the hidden command the proxy tab runs
+func registerProxyCommand(rootCmd *cobra.Command) {
+	proxyCmd := &cobra.Command{
+		Use:    "proxy [service...]",
+		Short:  "Run the reverse proxy configured in [proxy] (used by generated layouts)",
+		Hidden: true,
+		Run:    runProxy,
+	}
+
+	proxyCmd.Flags().String("config", "runtime.toml", "path to runtime.toml")
+	rootCmd.AddCommand(proxyCmd)
+}
+
+// proxyPaneArgs returns the command line for the proxy tab
+func proxyPaneArgs(config utils.Config) []string {
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "runtime"
+	}
+
+	args := []string{executable, "proxy", "--config", filepath.Join(config.Dir, "runtime.toml")}
+	return append(args, config.ServiceNames()...)
+}
+
+func runProxy(cmd *cobra.Command, args []string) {
+	configPath, _ := cmd.Flags().GetString("config")
+
+	parsedConfig, err := utils.ParseConfig(configPath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	// Route only to the services running in this session
+	parsedConfig, err = parsedConfig.Select("", args)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer cancel()
+
+	if err := serveProxy(ctx, parsedConfig); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// serveProxy runs the reverse proxy until ctx is cancelled
+func serveProxy(ctx context.Context, config utils.Config) error {
+	assigned, err := ports.Load(config.Dir)
+	if err != nil {
+		return err
+	}
+
+	server := &proxy.Server{Config: config, Assigned: assigned, Output: os.Stdout}
+	return server.Run(ctx)
+}
@@ -0,0 +1,85 @@
+package dev
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/The-Pirateship/runtime/pkg/supervisor"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// registerSuperviseCommand adds the hidden command each generated pane runs.
+// It waits for the service's dependencies, then runs the service in the foreground.
+func registerSuperviseCommand(rootCmd *cobra.Command) {
+	superviseCmd := &cobra.Command{
+		Use:    "supervise <service>",
+		Short:  "Run a single service (used by generated layouts)",
+		Args:   cobra.ExactArgs(1),
+		Hidden: true,
+		Run:    runSupervise,
+	}
+
+	superviseCmd.Flags().String("config", "runtime.toml", "path to runtime.toml")
+	superviseCmd.Flags().String("multiplexer", "", "multiplexer the pane runs in, recorded so runtime stop can end the session")
+	rootCmd.AddCommand(superviseCmd)
+}
+
+func runSupervise(cmd *cobra.Command, args []string) {
+	configPath, _ := cmd.Flags().GetString("config")
+
+	parsedConfig, err := utils.ParseConfig(configPath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	service, ok := parsedConfig.Service(args[0])
+	if !ok {
+		fmt.Printf("❌ Service '%s' not found in %s\n", args[0], configPath)
+		os.Exit(1)
+	}
+
+	// Stop when the pane is closed or we're asked to terminate
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+
+	runner := &supervisor.Runner{
+		Config:   parsedConfig,
+		Service:  service,
+		Stdin:    os.Stdin,
+		Stdout:   os.Stdout,
+		Stderr:   os.Stderr,
+		Terminal: term.IsTerminal(int(os.Stdin.Fd())),
+	}
+
+	multiplexerName, _ := cmd.Flags().GetString("multiplexer")
+	if mux, ok := findMultiplexer(multiplexerName); ok {
+		runner.Multiplexer = mux.Name()
+		runner.Session = mux.CurrentSession()
+	}
+
+	// Ctrl-C reaches a running service directly through its terminal. Once
+	// the service has exited, Ctrl-C closes the pane's supervisor instead.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		for range interrupts {
+			if !runner.Running() {
+				stop()
+			}
+		}
+	}()
+
+	exitCode, err := runner.Run(ctx)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	os.Exit(exitCode)
+}
@@ -0,0 +1,103 @@
+package dev
+
+import (
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// tab is one tab (Zellij) or window (tmux) in a dev session
+type tab struct {
+	name  string
+	split string // utils.SplitVertical or utils.SplitHorizontal
+	panes []pane
+}
+
+// pane is a single pane inside a tab
+type pane struct {
+	name string
+	args []string // command line to run; empty for an interactive shell
+	cwd  string
+	size string // optional, like "60%"
+}
+
+// buildTabs arranges the config's services and extra panes into tabs. Services get
+// a tab of their own unless [layout.tabs] groups them; a grouped tab takes the
+// position of its first service, and tabs holding only extra panes go last.
+func buildTabs(config utils.Config, multiplexer string) []tab {
+	var tabs []tab
+	emitted := map[string]bool{}
+
+	for _, service := range config.Services {
+		layoutTab, grouped := config.Layout.TabFor(service.Name)
+		if !grouped {
+			tabs = append(tabs, tab{
+				name:  service.Name,
+				split: utils.SplitVertical,
+				panes: []pane{{name: service.Name, args: superviseArgs(config, service, multiplexer), cwd: service.Path}},
+			})
+			continue
+		}
+
+		if !emitted[layoutTab.Name] {
+			emitted[layoutTab.Name] = true
+			tabs = append(tabs, layoutTabPanes(config, layoutTab, multiplexer))
+		}
+	}
+
+	for _, layoutTab := range config.Layout.Tabs {
+		if emitted[layoutTab.Name] {
+			continue
+		}
+
+		// Tabs whose services were all left out of this run still show their extra panes
+		if t := layoutTabPanes(config, layoutTab, multiplexer); len(t.panes) > 0 {
+			tabs = append(tabs, t)
+		}
+	}
+
+	if config.Proxy != nil {
+		tabs = append(tabs, tab{
+			name:  "proxy",
+			split: utils.SplitVertical,
+			panes: []pane{{name: "proxy", args: proxyPaneArgs(config), cwd: config.Dir}},
+		})
+	}
+
+	// Show readiness in a dedicated tab when there is something to wait for
+	if hasHealthChecks(config) {
+		tabs = append(tabs, tab{
+			name:  "status",
+			split: utils.SplitVertical,
+			panes: []pane{{name: "status", args: statusPaneArgs(config), cwd: config.Dir}},
+		})
+	}
+
+	return tabs
+}
+
+// layoutTabPanes resolves a [layout.tabs] entry, skipping services that aren't part of this run
+func layoutTabPanes(config utils.Config, layoutTab utils.LayoutTab, multiplexer string) tab {
+	t := tab{name: layoutTab.Name, split: layoutTab.Split}
+
+	for i, name := range layoutTab.Panes {
+		p := pane{name: name}
+		if layoutTab.Sizes != nil {
+			p.size = layoutTab.Sizes[i]
+		}
+
+		if service, ok := config.Service(name); ok {
+			p.args = superviseArgs(config, service, multiplexer)
+			p.cwd = service.Path
+		} else if extra, ok := config.Layout.Panes[name]; ok {
+			if extra.Command != "" {
+				p.args = []string{"sh", "-c", extra.Command}
+			}
+			p.cwd = extra.Path
+		} else {
+			continue
+		}
+
+		t.panes = append(t.panes, p)
+	}
+
+	return t
+}
@@ -0,0 +1,173 @@
+package dev
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// tmuxMultiplexer runs services in a tmux session with one window per service
+type tmuxMultiplexer struct{}
+
+func (tmuxMultiplexer) Name() string {
+	return "tmux"
+}
+
+func (tmuxMultiplexer) InstallHint() []string {
+	return []string{
+		"brew install tmux",
+		"or visit: https://github.com/tmux/tmux/wiki/Installing",
+	}
+}
+
+func (mux tmuxMultiplexer) Launch(config utils.Config, session string) error {
+	fmt.Printf("🚀 Launching tmux session '%s'\n", session)
+
+	var sessionID string
+	for i, t := range buildTabs(config, "tmux") {
+		args := []string{"new-window", "-t", sessionID + ":"}
+		if i == 0 {
+			// Create the session detached so every window exists before we attach
+			args = []string{"new-session", "-d", "-s", session}
+		}
+
+		first := t.panes[0]
+		args = append(args, "-P", "-F", "#{session_id} #{window_id} #{pane_id}", "-n", t.name, "-c", first.cwd)
+		if len(first.args) > 0 {
+			args = append(args, shellCommand(first.args))
+		}
+
+		output, err := tmuxOutput(args...)
+		if err != nil {
+			return fmt.Errorf("failed to create tmux window for %s: %w", t.name, err)
+		}
+		ids := strings.Fields(output)
+		sessionID = ids[0]
+		window, firstPaneID := ids[1], ids[2]
+
+		// Keep the window open after a service exits so its output can be read, like Zellij does
+		if _, err := tmuxOutput("set-option", "-w", "-t", window, "remain-on-exit", "on"); err != nil {
+			return fmt.Errorf("failed to configure tmux window: %w", err)
+		}
+
+		if err := splitTmuxWindow(window, firstPaneID, t); err != nil {
+			return err
+		}
+	}
+
+	// Start on the first service's window
+	if _, err := tmuxOutput("select-window", "-t", sessionID+":^"); err != nil {
+		return fmt.Errorf("failed to select tmux window: %w", err)
+	}
+
+	return mux.Attach(session)
+}
+
+func (tmuxMultiplexer) SessionExists(session string) bool {
+	// A leading = makes tmux match the name exactly rather than as a prefix
+	_, err := tmuxOutput("has-session", "-t", "="+session)
+	return err == nil
+}
+
+func (tmuxMultiplexer) Attach(session string) error {
+	// Inside tmux already, switch this client over instead of nesting sessions
+	attach := "attach-session"
+	if os.Getenv("TMUX") != "" {
+		attach = "switch-client"
+	}
+
+	tmuxCmd := exec.Command("tmux", attach, "-t", "="+session)
+	tmuxCmd.Stdin = os.Stdin
+	tmuxCmd.Stdout = os.Stdout
+	tmuxCmd.Stderr = os.Stderr
+
+	if err := tmuxCmd.Run(); err != nil {
+		return fmt.Errorf("failed to attach to tmux: %w", err)
+	}
+
+	return nil
+}
+
+// splitTmuxWindow adds the rest of a tab's panes to its window, then evens them out
+// and applies any configured sizes
+func splitTmuxWindow(window, firstPaneID string, t tab) error {
+	if len(t.panes) == 1 {
+		return nil
+	}
+	paneIDs := []string{firstPaneID}
+
+	// tmux calls side-by-side panes a horizontal split, the opposite of Zellij
+	splitFlag, layout, sizeFlag := "-h", "even-horizontal", "-x"
+	if t.split == utils.SplitHorizontal {
+		splitFlag, layout, sizeFlag = "-v", "even-vertical", "-y"
+	}
+
+	for _, p := range t.panes[1:] {
+		args := []string{"split-window", splitFlag, "-t", window, "-P", "-F", "#{pane_id}", "-c", p.cwd}
+		if len(p.args) > 0 {
+			args = append(args, shellCommand(p.args))
+		}
+
+		paneID, err := tmuxOutput(args...)
+		if err != nil {
+			return fmt.Errorf("failed to create tmux pane for %s: %w", p.name, err)
+		}
+		paneIDs = append(paneIDs, paneID)
+	}
+
+	if _, err := tmuxOutput("select-layout", "-t", window, layout); err != nil {
+		return fmt.Errorf("failed to arrange tmux panes: %w", err)
+	}
+
+	// The last pane takes whatever space is left over
+	for i, p := range t.panes[:len(t.panes)-1] {
+		if p.size == "" {
+			continue
+		}
+		if _, err := tmuxOutput("resize-pane", "-t", paneIDs[i], sizeFlag, p.size); err != nil {
+			return fmt.Errorf("failed to resize tmux pane %s: %w", p.name, err)
+		}
+	}
+
+	for i, p := range t.panes {
+		tmuxOutput("select-pane", "-t", paneIDs[i], "-T", p.name)
+	}
+
+	return nil
+}
+
+// tmuxOutput runs a tmux command and returns its trimmed output
+func tmuxOutput(args ...string) (string, error) {
+	output, err := exec.Command("tmux", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (tmuxMultiplexer) KillSession(session string) error {
+	if _, err := tmuxOutput("kill-session", "-t", "="+session); err != nil {
+		return fmt.Errorf("failed to end tmux session '%s': %w", session, err)
+	}
+	return nil
+}
+
+func (tmuxMultiplexer) CurrentSession() string {
+	name, err := tmuxOutput("display-message", "-p", "-t", os.Getenv("TMUX_PANE"), "#S")
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// shellCommand joins arguments into one shell command line, since tmux runs window commands through a shell
+func shellCommand(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = utils.ShellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,106 @@
+package dev
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// zellijMultiplexer runs services in Zellij using a generated layout and config
+type zellijMultiplexer struct{}
+
+func (zellijMultiplexer) Name() string {
+	return "zellij"
+}
+
+func (zellijMultiplexer) InstallHint() []string {
+	return []string{
+		"brew install zellij",
+		"or visit: https://zellij.dev/documentation/installation",
+	}
+}
+
+func (zellijMultiplexer) CurrentSession() string {
+	return os.Getenv("ZELLIJ_SESSION_NAME")
+}
+
+func (zellijMultiplexer) Launch(config utils.Config, session string) error {
+	// Generate Zellij layout
+	if err := generateZellijLayout(config); err != nil {
+		return fmt.Errorf("failed to generate Zellij layout: %w", err)
+	}
+
+	// Generate Zellij config
+	if err := generateZellijConfig(); err != nil {
+		return fmt.Errorf("failed to generate Zellij config: %w", err)
+	}
+
+	// Launch Zellij with the generated config and layout
+	configPath := filepath.Join(".zellij", "config.kdl")
+	layoutPath := filepath.Join(".zellij", "layout.kdl")
+	fmt.Printf("🚀 Launching Zellij session '%s' with config: %s and layout: %s\n", session, configPath, layoutPath)
+
+	// An exited session would be resurrected with its old layout, so start over instead
+	if running, exists := zellijSessions()[session]; exists && !running {
+		exec.Command("zellij", "delete-session", session).Run()
+	}
+
+	return runZellij("--config", configPath, "--layout", layoutPath, "--session", session)
+}
+
+func (zellijMultiplexer) SessionExists(session string) bool {
+	return zellijSessions()[session]
+}
+
+func (zellijMultiplexer) Attach(session string) error {
+	return runZellij("attach", session)
+}
+
+func (zellijMultiplexer) KillSession(session string) error {
+	// --force kills a running session before deleting it
+	if output, err := exec.Command("zellij", "delete-session", "--force", session).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to end Zellij session '%s': %w: %s", session, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// zellijSessions returns every session Zellij knows about, mapped to whether it is
+// still running. Exited sessions are kept around so they can be resurrected.
+func zellijSessions() map[string]bool {
+	sessions := map[string]bool{}
+
+	// Zellij exits with an error when there are no sessions at all
+	output, err := exec.Command("zellij", "list-sessions", "--no-formatting").Output()
+	if err != nil {
+		return sessions
+	}
+
+	// Lines look like "name [Created 1h ago] (EXITED - attach to resurrect)"
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		sessions[fields[0]] = !strings.Contains(line, "EXITED")
+	}
+
+	return sessions
+}
+
+// runZellij runs zellij in the foreground on this terminal
+func runZellij(args ...string) error {
+	zellijCmd := exec.Command("zellij", args...)
+	zellijCmd.Stdin = os.Stdin
+	zellijCmd.Stdout = os.Stdout
+	zellijCmd.Stderr = os.Stderr
+
+	if err := zellijCmd.Run(); err != nil {
+		return fmt.Errorf("failed to run Zellij: %w", err)
+	}
+
+	return nil
+}
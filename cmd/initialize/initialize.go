@@ -0,0 +1,128 @@
+package initialize
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/The-Pirateship/runtime/pkg/detect"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func RegisterCommand(rootCmd *cobra.Command) {
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create a runtime.toml by detecting the services in this repository",
+		Long:  "Create a runtime.toml by detecting the services in this repository: package.json scripts, Go main packages, Cargo binaries, Django and pyproject apps, Cloudflare Workers and docker compose services.",
+		Args:  cobra.NoArgs,
+		Run:   runInit,
+	}
+
+	initCmd.Flags().BoolP("yes", "y", false, "add every detected service without asking")
+	initCmd.Flags().Bool("force", false, "overwrite an existing runtime.toml")
+	rootCmd.AddCommand(initCmd)
+}
+
+func runInit(cmd *cobra.Command, args []string) {
+	const configPath = "runtime.toml"
+
+	force, _ := cmd.Flags().GetBool("force")
+	if _, err := os.Stat(configPath); err == nil && !force {
+		fmt.Println("❌ runtime.toml already exists. Use --force to replace it")
+		os.Exit(1)
+	}
+
+	fmt.Println("🔍 Looking for services...")
+	services, err := detect.Detect(".")
+	if err != nil {
+		fmt.Printf("❌ Failed to scan the repository: %v\n", err)
+		os.Exit(1)
+	}
+	if len(services) == 0 {
+		fmt.Println("❌ No services found. Create runtime.toml by hand, see https://github.com/The-Pirateship/runtime")
+		os.Exit(1)
+	}
+
+	yes, _ := cmd.Flags().GetBool("yes")
+	if !yes {
+		services = confirmServices(services)
+		if len(services) == 0 {
+			fmt.Println("👋 No services selected, runtime.toml was not written")
+			return
+		}
+	}
+
+	projectDir, _ := filepath.Abs(".")
+	if err := writeConfig(configPath, filepath.Base(projectDir), services); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Wrote runtime.toml with %d service(s). Start them with: runtime dev\n", len(services))
+}
+
+// confirmServices asks about each detected service and returns the ones to keep
+func confirmServices(services []detect.Service) []detect.Service {
+	reader := bufio.NewReader(os.Stdin)
+	var selected []detect.Service
+
+	for _, service := range services {
+		fmt.Printf("\n📦 %s (from %s)\n", service.Name, service.Source)
+		fmt.Printf("   path:       %s\n", configPathValue(service.Path))
+		fmt.Printf("   runCommand: %s\n", service.Command)
+		fmt.Print("   Add it? [Y/n] ")
+
+		answer, err := reader.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Println("\n❌ No answer given. Run with --yes to add every detected service")
+			os.Exit(1)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "y", "yes":
+			selected = append(selected, service)
+		}
+	}
+
+	fmt.Println()
+	return selected
+}
+
+// configPathValue formats a service path the way runtime.toml writes them, relative
+// to the file with a leading slash
+func configPathValue(path string) string {
+	if path == "." {
+		return "/"
+	}
+	return "/" + path
+}
+
+// writeConfig renders the services as runtime.toml and checks the result parses
+// before replacing anything on disk
+func writeConfig(configPath, projectName string, services []detect.Service) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "name = %s\n", strconv.Quote(projectName))
+
+	for _, service := range services {
+		fmt.Fprintf(&b, "\n# Detected from %s\n", service.Source)
+		fmt.Fprintf(&b, "[services.%s]\n", service.Name)
+		fmt.Fprintf(&b, "path = %s\n", strconv.Quote(configPathValue(service.Path)))
+		fmt.Fprintf(&b, "runCommand = %s\n", strconv.Quote(service.Command))
+	}
+
+	tmpPath := configPath + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+
+	if _, err := utils.ParseConfig(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("generated config is invalid: %w", err)
+	}
+
+	return os.Rename(tmpPath, configPath)
+}
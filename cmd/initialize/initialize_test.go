@@ -0,0 +1,70 @@
+package initialize
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/The-Pirateship/runtime/pkg/detect"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// TestWriteConfigKeepsDetectedOrder writes the services detected in the detect
+// testdata repository and checks runtime.toml reads back the same services, in the
+// order they were found. Paths aren't checked to exist, so any directory will do.
+func TestWriteConfigKeepsDetectedOrder(t *testing.T) {
+	root := filepath.Join("..", "..", "pkg", "detect", "testdata", "repo")
+	services, err := detect.Detect(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "runtime.toml")
+	if err := writeConfig(configPath, "repo", services); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := utils.ParseConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want []string
+	for _, service := range services {
+		want = append(want, service.Name)
+	}
+	if got := config.ServiceNames(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("runtime.toml lists %v, want %v", got, want)
+	}
+
+	for i, service := range config.Services {
+		if service.Command != services[i].Command {
+			t.Errorf("%s runs %q, want %q", service.Name, service.Command, services[i].Command)
+		}
+		if want := filepath.Join(dir, services[i].Path); service.Path != want {
+			t.Errorf("%s is in %s, want %s", service.Name, service.Path, want)
+		}
+	}
+}
+
+// TestWriteConfigRejectsInvalidConfig checks nothing is written when the generated
+// file doesn't parse
+func TestWriteConfigRejectsInvalidConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "runtime.toml")
+	services := []detect.Service{
+		{Name: "api", Path: "api", Command: "go run .", Source: "api/go.mod"},
+		{Name: "api", Path: "api", Command: "go run .", Source: "api/go.mod"},
+	}
+
+	if err := writeConfig(configPath, "repo", services); err == nil {
+		t.Fatal("writeConfig accepted a service written twice")
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Errorf("runtime.toml was written anyway")
+	}
+	if _, err := os.Stat(configPath + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("the temporary file was left behind")
+	}
+}
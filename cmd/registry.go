@@ -5,6 +5,15 @@ import (
 
 	"github.com/The-Pirateship/runtime/cmd/deploy"
 	"github.com/The-Pirateship/runtime/cmd/dev"
+	"github.com/The-Pirateship/runtime/cmd/initialize"
+	"github.com/The-Pirateship/runtime/cmd/schema"
+	"github.com/The-Pirateship/runtime/cmd/session"
+	"github.com/The-Pirateship/runtime/cmd/validate"
+	"github.com/The-Pirateship/runtime/pkg/dockerConnector"
+	"github.com/The-Pirateship/runtime/pkg/gcpConnector"
+	"github.com/The-Pirateship/runtime/pkg/provider"
+	"github.com/The-Pirateship/runtime/pkg/sshConnector"
+	"github.com/The-Pirateship/runtime/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -28,10 +37,26 @@ func Execute() {
 func RegisterAllCommands(rootCmd *cobra.Command) {
 	dev.RegisterCommand(rootCmd)
 	deploy.RegisterCommand(rootCmd)
+	session.RegisterCommand(rootCmd)
+	initialize.RegisterCommand(rootCmd)
+	validate.RegisterCommand(rootCmd)
+	schema.RegisterCommand(rootCmd)
+}
+
+// RegisterAllProviders registers the deploy targets runsOn can name, by prefix
+func RegisterAllProviders() {
+	provider.Register(gcpConnector.RunsOnPrefix, gcpConnector.NewProvider())
+
+	ssh := sshConnector.NewProvider()
+	provider.Register(sshConnector.URLPrefix, ssh)
+	provider.Register(utils.HostRunsOnPrefix, ssh)
+
+	provider.Register(dockerConnector.RunsOnPrefix, dockerConnector.NewProvider())
 }
 
 func init() {
-	// Register all commands using the centralized registry
+	// Register all commands and deploy targets using the centralized registry
+	RegisterAllProviders()
 	RegisterAllCommands(rootCmd)
 
 	// Hide default completion command
@@ -0,0 +1,43 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func RegisterCommand(rootCmd *cobra.Command) {
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print a JSON Schema for runtime.toml",
+		Long:  "Print a JSON Schema for runtime.toml, so editors like VS Code (with Even Better TOML) or Taplo can validate and autocomplete it.",
+		Args:  cobra.NoArgs,
+		Run:   runSchema,
+	}
+
+	schemaCmd.Flags().StringP("output", "o", "", "write the schema to this file instead of stdout")
+	rootCmd.AddCommand(schemaCmd)
+}
+
+func runSchema(cmd *cobra.Command, args []string) {
+	data, err := utils.JSONSchema()
+	if err != nil {
+		fmt.Printf("❌ Failed to generate schema: %v\n", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		fmt.Printf("❌ Failed to write %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Wrote the runtime.toml schema to %s\n", output)
+}
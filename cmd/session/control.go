@@ -0,0 +1,207 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/supervisor"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// commandTimeout is how long to wait for a supervisor to carry out a command. It
+// covers the service's grace period plus its time to start.
+const commandTimeout = 30 * time.Second
+
+func runRestart(cmd *cobra.Command, args []string) {
+	parsedConfig := loadConfig(cmd)
+	stateDir := supervisor.StateDir(parsedConfig.Dir)
+
+	failed := false
+	for _, service := range lookupServices(parsedConfig, args) {
+		if err := restartService(stateDir, service); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func runStop(cmd *cobra.Command, args []string) {
+	parsedConfig := loadConfig(cmd)
+	stateDir := supervisor.StateDir(parsedConfig.Dir)
+
+	services := lookupServices(parsedConfig, args)
+	stopSession := len(args) == 0
+	if stopSession {
+		services = parsedConfig.Services
+	}
+
+	failed := false
+	running := false
+	var states []supervisor.State
+	for _, service := range services {
+		state, err := supervisor.ReadState(stateDir, service.Name)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		if !state.Supervised() {
+			if !stopSession {
+				fmt.Printf("❌ '%s' isn't running in a dev session\n", service.Name)
+				failed = true
+			}
+			continue
+		}
+
+		running = true
+		states = append(states, state)
+		if err := stopService(stateDir, state); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			failed = true
+		}
+	}
+
+	if stopSession {
+		if !running {
+			fmt.Println("ℹ️  No dev session is running")
+			return
+		}
+		if err := endSessions(states); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// restartService asks the service's supervisor to restart it and waits until it is running again
+func restartService(stateDir string, service utils.Service) error {
+	state, err := supervisor.ReadState(stateDir, service.Name)
+	if err != nil {
+		return err
+	}
+	if !state.Supervised() {
+		return fmt.Errorf("'%s' isn't running in a dev session, start it with runtime dev", service.Name)
+	}
+	if state.Status == supervisor.StatusWaiting {
+		return fmt.Errorf("'%s' is still waiting for its dependencies", service.Name)
+	}
+
+	fmt.Printf("🔄 Restarting %s...\n", service.Name)
+	sent := time.Now()
+	if err := supervisor.SendCommand(stateDir, service.Name, supervisor.CommandRestart); err != nil {
+		return err
+	}
+
+	state, err = waitForState(stateDir, service.Name, func(s supervisor.State) bool {
+		return s.PID != 0 && s.StartedAt.After(sent)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Restarted %s (pid %d)\n", service.Name, state.PID)
+	return nil
+}
+
+// stopService asks the service's supervisor to stop it and waits until it has
+func stopService(stateDir string, state supervisor.State) error {
+	if state.Status == supervisor.StatusExited || state.Status == supervisor.StatusStopped {
+		fmt.Printf("✅ %s is already stopped (last exit code %d)\n", state.Service, state.ExitCode)
+		return nil
+	}
+	if state.Status == supervisor.StatusWaiting {
+		fmt.Printf("⏭️  %s hasn't started yet\n", state.Service)
+		return nil
+	}
+
+	fmt.Printf("⏹️  Stopping %s...\n", state.Service)
+	if err := supervisor.SendCommand(stateDir, state.Service, supervisor.CommandStop); err != nil {
+		return err
+	}
+
+	state, err := waitForState(stateDir, state.Service, func(s supervisor.State) bool {
+		return s.Status == supervisor.StatusStopped || s.Status == supervisor.StatusExited
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Stopped %s (exit code %d)\n", state.Service, state.ExitCode)
+	return nil
+}
+
+// waitForState polls a service's state until done returns true
+func waitForState(stateDir, service string, done func(supervisor.State) bool) (supervisor.State, error) {
+	deadline := time.Now().Add(commandTimeout)
+
+	for time.Now().Before(deadline) {
+		state, err := supervisor.ReadState(stateDir, service)
+		if err != nil {
+			return supervisor.State{}, err
+		}
+		if done(state) {
+			return state, nil
+		}
+		if !state.Supervised() {
+			return supervisor.State{}, fmt.Errorf("the supervisor for '%s' exited", service)
+		}
+
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	return supervisor.State{}, fmt.Errorf("timed out after %v waiting for '%s'", commandTimeout, service)
+}
+
+// endSessions closes the multiplexer sessions (or plain mode processes) the services ran in
+func endSessions(states []supervisor.State) error {
+	ended := map[string]bool{}
+
+	for _, state := range states {
+		// Every pane of a multiplexer session shares it, while plain mode has one process
+		key := state.Multiplexer + "/" + state.Session
+		if state.Multiplexer == "" {
+			key = fmt.Sprint(state.SupervisorPID)
+		}
+		if ended[key] {
+			continue
+		}
+		ended[key] = true
+
+		switch state.Multiplexer {
+		case "zellij":
+			if err := exec.Command("zellij", "kill-session", state.Session).Run(); err != nil {
+				return fmt.Errorf("failed to end zellij session '%s': %w", state.Session, err)
+			}
+			fmt.Printf("👋 Ended zellij session '%s'\n", state.Session)
+		case "tmux":
+			// "=" matches the name exactly, so a prefix of another session's name can't end it
+			if err := exec.Command("tmux", "kill-session", "-t", "="+state.Session).Run(); err != nil {
+				return fmt.Errorf("failed to end tmux session '%s': %w", state.Session, err)
+			}
+			fmt.Printf("👋 Ended tmux session '%s'\n", state.Session)
+		default:
+			// Plain mode: ask runtime dev to shut down like it would on Ctrl-C
+			process, err := os.FindProcess(state.SupervisorPID)
+			if err != nil {
+				return fmt.Errorf("failed to find runtime dev (pid %d): %w", state.SupervisorPID, err)
+			}
+			if err := process.Signal(syscall.SIGTERM); err != nil {
+				process.Kill()
+			}
+			fmt.Printf("👋 Ended runtime dev (pid %d)\n", state.SupervisorPID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/logs"
+	"github.com/spf13/cobra"
+)
+
+// registerLogsCommand adds runtime logs, which shows a service's output from .runtime/logs
+func registerLogsCommand(rootCmd *cobra.Command) {
+	logsCmd := &cobra.Command{
+		Use:   "logs <service>",
+		Short: "Show a service's output from runtime dev",
+		Long:  "Show a service's output from runtime dev. Output is kept in .runtime/logs, so it is available after it has scrolled out of the pane or the session has ended.",
+		Args:  cobra.ExactArgs(1),
+		Run:   runLogs,
+	}
+
+	logsCmd.Flags().String("config", "runtime.toml", "path to runtime.toml")
+	logsCmd.Flags().BoolP("follow", "f", false, "keep printing new output as it is logged")
+	logsCmd.Flags().String("since", "", "only show output since a duration ago (like 10m) or a time (like 2024-01-02T15:04:05)")
+	logsCmd.Flags().IntP("lines", "n", 0, "only show the last N lines (default: all)")
+	logsCmd.Flags().BoolP("timestamps", "t", false, "show when each line was logged")
+	rootCmd.AddCommand(logsCmd)
+}
+
+func runLogs(cmd *cobra.Command, args []string) {
+	parsedConfig := loadConfig(cmd)
+	service := lookupServices(parsedConfig, args)[0]
+
+	sinceFlag, _ := cmd.Flags().GetString("since")
+	since, err := parseSince(sinceFlag, time.Now())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	lines, offset, err := logs.Read(parsedConfig.Dir, service.Name, since)
+	if err != nil {
+		fmt.Printf("❌ Failed to read logs for '%s': %v\n", service.Name, err)
+		os.Exit(1)
+	}
+
+	count, _ := cmd.Flags().GetInt("lines")
+	if count > 0 && len(lines) > count {
+		lines = lines[len(lines)-count:]
+	}
+
+	timestamps, _ := cmd.Flags().GetBool("timestamps")
+	printLine := func(line logs.Line) {
+		if timestamps && !line.Time.IsZero() {
+			fmt.Printf("%s %s\n", line.Time.Local().Format("2006-01-02 15:04:05.000"), line.Text)
+			return
+		}
+		fmt.Println(line.Text)
+	}
+
+	for _, line := range lines {
+		printLine(line)
+	}
+
+	follow, _ := cmd.Flags().GetBool("follow")
+	if !follow {
+		if len(lines) == 0 && sinceFlag == "" {
+			fmt.Printf("ℹ️  No logs for '%s' yet. Output is logged while it runs with runtime dev.\n", service.Name)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := logs.Follow(ctx, parsedConfig.Dir, service.Name, offset, printLine); err != nil {
+		fmt.Printf("❌ Failed to follow logs for '%s': %v\n", service.Name, err)
+		os.Exit(1)
+	}
+}
+
+// parseSince reads --since as a duration before now or as an absolute time
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	// A bare time of day means today
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			year, month, day := now.Date()
+			return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since '%s', expected a duration like 10m or a time like 2024-01-02T15:04:05 or 15:04", value)
+}
@@ -0,0 +1,99 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/supervisor"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// RegisterCommand adds the commands that inspect and control a running dev session
+func RegisterCommand(rootCmd *cobra.Command) {
+	statusCmd := &cobra.Command{
+		Use:   "status [service...]",
+		Short: "Show the state of services in the running dev session",
+		Run:   runStatus,
+	}
+	statusCmd.Flags().String("config", "runtime.toml", "path to runtime.toml")
+	statusCmd.Flags().BoolP("watch", "w", false, "keep refreshing the table every second")
+
+	restartCmd := &cobra.Command{
+		Use:   "restart <service...>",
+		Short: "Restart services in the running dev session",
+		Long:  "Restart services in the running dev session. Services that have exited or were stopped are started again.",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runRestart,
+	}
+	restartCmd.Flags().String("config", "runtime.toml", "path to runtime.toml")
+
+	stopCmd := &cobra.Command{
+		Use:   "stop [service...]",
+		Short: "Stop services, or the whole dev session",
+		Long:  "Stop services in the running dev session, keeping their panes so they can be restarted. Without arguments, stops every service and ends the session.",
+		Run:   runStop,
+	}
+	stopCmd.Flags().String("config", "runtime.toml", "path to runtime.toml")
+
+	rootCmd.AddCommand(statusCmd, restartCmd, stopCmd)
+	registerLogsCommand(rootCmd)
+}
+
+// loadConfig parses the config named by the --config flag, exiting on errors
+func loadConfig(cmd *cobra.Command) utils.Config {
+	configPath, _ := cmd.Flags().GetString("config")
+
+	parsedConfig, err := utils.ParseConfig(configPath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	return parsedConfig
+}
+
+// lookupServices returns the named services, exiting if any of them isn't in the config
+func lookupServices(config utils.Config, names []string) []utils.Service {
+	var services []utils.Service
+	for _, name := range names {
+		service, ok := config.Service(name)
+		if !ok {
+			fmt.Printf("❌ Service '%s' not found in runtime.toml\n", name)
+			os.Exit(1)
+		}
+		services = append(services, service)
+	}
+	return services
+}
+
+func runStatus(cmd *cobra.Command, args []string) {
+	parsedConfig := loadConfig(cmd)
+	lookupServices(parsedConfig, args)
+
+	// Show only the named services, in config order
+	parsedConfig, err := parsedConfig.Select("", args)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	watch, _ := cmd.Flags().GetBool("watch")
+	if !watch {
+		if err := supervisor.PrintStatus(os.Stdout, parsedConfig); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	for {
+		// Clear the screen and redraw from the top left
+		fmt.Print("\033[H\033[2J")
+		if err := supervisor.PrintStatus(os.Stdout, parsedConfig); err != nil {
+			fmt.Printf("❌ %v\n", err)
+		}
+		time.Sleep(time.Second)
+	}
+}
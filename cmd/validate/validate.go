@@ -0,0 +1,74 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/The-Pirateship/runtime/pkg/provider"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func RegisterCommand(rootCmd *cobra.Command) {
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check runtime.toml for mistakes without running anything",
+		Long:  "Check runtime.toml for mistakes without running anything: unknown keys, wrong types, paths that don't exist, duplicate ports, unsupported runsOn values and dependency cycles.",
+		Args:  cobra.NoArgs,
+		Run:   runValidate,
+	}
+
+	validateCmd.Flags().String("config", "runtime.toml", "path to runtime.toml")
+	validateCmd.Flags().String("format", "text", "output format: \"text\" or \"json\"")
+	rootCmd.AddCommand(validateCmd)
+}
+
+// problem is a ConfigError as written by --format json
+type problem struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+}
+
+type report struct {
+	Valid    bool      `json:"valid"`
+	Problems []problem `json:"problems"`
+}
+
+func runValidate(cmd *cobra.Command, args []string) {
+	configPath, _ := cmd.Flags().GetString("config")
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "json" {
+		fmt.Printf("❌ Unknown format '%s', expected \"text\" or \"json\"\n", format)
+		os.Exit(1)
+	}
+
+	problems := utils.Validate(configPath, provider.CheckRunsOn)
+
+	if format == "json" {
+		result := report{Valid: len(problems) == 0, Problems: []problem{}}
+		for _, p := range problems {
+			result.Problems = append(result.Problems, problem{File: p.File, Line: p.Line, Column: p.Column, Key: p.Key, Message: p.Message})
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+	} else {
+		for _, p := range problems {
+			fmt.Printf("❌ %v\n", p)
+		}
+		if len(problems) == 0 {
+			fmt.Printf("✅ %s is valid\n", configPath)
+		} else {
+			fmt.Printf("\nFound %d problem(s) in %s\n", len(problems), configPath)
+		}
+	}
+
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
@@ -5,8 +5,12 @@ go 1.24.0
 toolchain go1.24.11
 
 require (
+	github.com/creack/pty v1.1.24
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/pelletier/go-toml v1.9.4
 	github.com/spf13/cobra v1.3.0
+	golang.org/x/term v0.38.0
+	google.golang.org/api v0.259.0
 )
 
 require (
@@ -32,7 +36,6 @@ require (
 	golang.org/x/oauth2 v0.34.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
 	golang.org/x/text v0.32.0 // indirect
-	google.golang.org/api v0.259.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20251222181119-0a764e51fe1b // indirect
 	google.golang.org/grpc v1.78.0 // indirect
 	google.golang.org/protobuf v1.36.11 // indirect
@@ -0,0 +1,54 @@
+package detect
+
+import (
+	"path/filepath"
+
+	"github.com/pelletier/go-toml"
+)
+
+// detectCargo finds Rust packages with a binary, one service per binary target
+func detectCargo(root, dir string) ([]Service, error) {
+	manifest := filepath.Join(dir, "Cargo.toml")
+	if !fileExists(manifest) {
+		return nil, nil
+	}
+
+	tree, err := toml.LoadFile(manifest)
+	if err != nil || !tree.Has("package") {
+		// Workspace roots have no package; their members are detected on their own
+		return nil, nil
+	}
+
+	var bins []string
+	if targets, ok := tree.Get("bin").([]*toml.Tree); ok {
+		for _, target := range targets {
+			if name, ok := target.Get("name").(string); ok {
+				bins = append(bins, name)
+			}
+		}
+	}
+
+	service := Service{
+		Name:    dirName(root, dir),
+		Path:    relative(root, dir),
+		Command: "cargo run",
+		Source:  relative(root, manifest),
+	}
+
+	switch {
+	case len(bins) > 1:
+		services := make([]Service, 0, len(bins))
+		for _, bin := range bins {
+			s := service
+			s.Name = serviceName(bin)
+			s.Command = "cargo run --bin " + bin
+			services = append(services, s)
+		}
+		return services, nil
+	case len(bins) == 1 || fileExists(filepath.Join(dir, "src", "main.rs")):
+		return []Service{service}, nil
+	default:
+		// A library crate
+		return nil, nil
+	}
+}
@@ -0,0 +1,85 @@
+package detect
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// composeFiles are the names docker compose picks up on its own, in its order of preference
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// detectCompose turns each service in a compose file into a service run with docker compose up
+func detectCompose(root, dir string) ([]Service, error) {
+	for _, name := range composeFiles {
+		path := filepath.Join(dir, name)
+		if !fileExists(path) {
+			continue
+		}
+
+		names, err := composeServices(path)
+		if err != nil {
+			return nil, err
+		}
+
+		services := make([]Service, 0, len(names))
+		for _, name := range names {
+			services = append(services, Service{
+				Name:    serviceName(name),
+				Path:    relative(root, dir),
+				Command: "docker compose up " + name,
+				Source:  relative(root, path),
+			})
+		}
+		return services, nil
+	}
+	return nil, nil
+}
+
+// composeServices lists the keys of the top-level services mapping. Only that much
+// of the YAML is needed, so it is read line by line rather than fully parsed.
+func composeServices(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var names []string
+	inServices := false
+	childIndent := -1
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		if indent == 0 {
+			inServices = strings.HasPrefix(trimmed, "services:")
+			continue
+		}
+		if !inServices {
+			continue
+		}
+
+		if childIndent == -1 {
+			childIndent = indent
+		}
+		if indent != childIndent {
+			continue
+		}
+
+		key, _, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		names = append(names, strings.Trim(key, `"'`))
+	}
+
+	return names, scanner.Err()
+}
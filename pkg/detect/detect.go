@@ -0,0 +1,159 @@
+package detect
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Service is a runnable service found in the repository
+type Service struct {
+	Name    string
+	Path    string // relative to the root, with forward slashes; "." for the root itself
+	Command string
+	Source  string // file the service was detected from, relative to the root
+}
+
+// skippedDirs hold dependencies, build output or tool state rather than services
+var skippedDirs = map[string]bool{
+	".git":         true,
+	".runtime":     true,
+	".zellij":      true,
+	".venv":        true,
+	"venv":         true,
+	"__pycache__":  true,
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+	"testdata":     true,
+}
+
+// detector looks for services defined by files in a single directory
+type detector func(root, dir string) ([]Service, error)
+
+// detectors run in this order for every directory
+var detectors = []detector{
+	detectCompose,
+	detectNode,
+	detectWrangler,
+	detectGo,
+	detectCargo,
+	detectPython,
+}
+
+// Detect walks the repository under root and returns the services it finds, in
+// path order with the root first. Names are unique and usable as TOML keys.
+func Detect(root string) ([]Service, error) {
+	var services []Service
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != root && (skippedDirs[entry.Name()] || strings.HasPrefix(entry.Name(), ".")) {
+			return filepath.SkipDir
+		}
+
+		for _, detect := range detectors {
+			detected, err := detect(root, path)
+			if err != nil {
+				return err
+			}
+			services = append(services, detected...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return uniqueNames(services), nil
+}
+
+// invalidNameChars are characters that can't appear in a bare TOML key
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// serviceName turns a directory or package name into a service name
+func serviceName(name string) string {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		return "app"
+	}
+	return name
+}
+
+// dirName names a service after its directory, or after the project for the root
+func dirName(root, dir string) string {
+	if dir == root {
+		absRoot, err := filepath.Abs(root)
+		if err == nil {
+			return serviceName(filepath.Base(absRoot))
+		}
+	}
+	return serviceName(filepath.Base(dir))
+}
+
+// relative returns dir relative to root with forward slashes
+func relative(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return dir
+	}
+	return filepath.ToSlash(rel)
+}
+
+// uniqueNames renames services that share a name, first by prefixing the directory
+// they run in (api/cmd/worker becomes api-worker) and then with a number
+func uniqueNames(services []Service) []Service {
+	count := map[string]int{}
+	for _, service := range services {
+		count[service.Name]++
+	}
+
+	taken := map[string]bool{}
+	for i, service := range services {
+		name := service.Name
+		if dir := serviceName(path.Base(service.Path)); count[name] > 1 && service.Path != "." && dir != name {
+			name = dir + "-" + name
+		}
+
+		unique := name
+		for n := 2; taken[unique]; n++ {
+			unique = name + "-" + strconv.Itoa(n)
+		}
+		taken[unique] = true
+		services[i].Name = unique
+	}
+
+	return services
+}
+
+// fileExists reports whether path is a regular file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// findUp looks for any of names in dir and its parents up to root, returning the first found
+func findUp(root, dir string, names ...string) string {
+	for {
+		for _, name := range names {
+			if fileExists(filepath.Join(dir, name)) {
+				return name
+			}
+		}
+		if dir == root || dir == filepath.Dir(dir) {
+			return ""
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,107 @@
+package detect
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDetect runs every detector over testdata/repo and checks the services found,
+// their order, the renames of clashing names and the directories left out
+func TestDetect(t *testing.T) {
+	services, err := Detect("testdata/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Service{
+		{Name: "db", Path: ".", Command: "docker compose up db", Source: "compose.yaml"},
+		{Name: "my-svc", Path: ".", Command: "docker compose up my.svc", Source: "compose.yaml"},
+		{Name: "repo", Path: ".", Command: "pnpm dev", Source: "package.json"},
+		{Name: "api", Path: "api", Command: "go run .", Source: "api/go.mod"},
+		// Clashes with worker below, so it is prefixed with its directory
+		{Name: "api-worker", Path: "api", Command: "go run ./cmd/worker", Source: "api/go.mod"},
+		{Name: "jobs", Path: "jobs", Command: "docker compose up jobs", Source: "jobs/compose.yml"},
+		// Same name in the same directory, so only a number tells them apart
+		{Name: "jobs-2", Path: "jobs", Command: "pnpm dev", Source: "jobs/package.json"},
+		{Name: "migrate", Path: "py", Command: "uv run migrate", Source: "py/pyproject.toml"},
+		{Name: "serve", Path: "py", Command: "uv run serve", Source: "py/pyproject.toml"},
+		{Name: "rust", Path: "rust", Command: "cargo run", Source: "rust/Cargo.toml"},
+		{Name: "site", Path: "site", Command: "python manage.py runserver", Source: "site/manage.py"},
+		{Name: "web", Path: "web", Command: "pnpm start", Source: "web/package.json"},
+		{Name: "worker", Path: "worker", Command: "npx wrangler dev", Source: "worker/wrangler.toml"},
+	}
+
+	if !reflect.DeepEqual(services, want) {
+		t.Errorf("detected:\n%s\nwant:\n%s", describe(services), describe(want))
+	}
+}
+
+func TestUniqueNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []Service
+		want     []string
+	}{
+		{
+			name:     "no clash",
+			services: []Service{{Name: "api", Path: "api"}, {Name: "web", Path: "web"}},
+			want:     []string{"api", "web"},
+		},
+		{
+			name:     "prefixed with the directory",
+			services: []Service{{Name: "worker", Path: "api"}, {Name: "worker", Path: "billing"}},
+			want:     []string{"api-worker", "billing-worker"},
+		},
+		{
+			name:     "root keeps its name",
+			services: []Service{{Name: "worker", Path: "."}, {Name: "worker", Path: "api"}},
+			want:     []string{"worker", "api-worker"},
+		},
+		{
+			name:     "numbered when the prefix doesn't help",
+			services: []Service{{Name: "app", Path: "app"}, {Name: "app", Path: "app"}, {Name: "app", Path: "app"}},
+			want:     []string{"app", "app-2", "app-3"},
+		},
+		{
+			name:     "prefixed name already taken",
+			services: []Service{{Name: "api-worker", Path: "tools"}, {Name: "worker", Path: "api"}, {Name: "worker", Path: "worker"}},
+			want:     []string{"api-worker", "api-worker-2", "worker"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var names []string
+			for _, service := range uniqueNames(tc.services) {
+				names = append(names, service.Name)
+			}
+			if !reflect.DeepEqual(names, tc.want) {
+				t.Errorf("got %v, want %v", names, tc.want)
+			}
+		})
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	tests := map[string]string{
+		"api":        "api",
+		"my.svc":     "my-svc",
+		"My App (2)": "My-App-2",
+		"@scope/pkg": "scope-pkg",
+		"...":        "app",
+	}
+
+	for name, want := range tests {
+		if got := serviceName(name); got != want {
+			t.Errorf("serviceName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func describe(services []Service) string {
+	var s string
+	for _, service := range services {
+		s += "  " + service.Name + " in " + service.Path + ": " + service.Command + " (" + service.Source + ")\n"
+	}
+	return s
+}
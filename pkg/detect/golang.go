@@ -0,0 +1,80 @@
+package detect
+
+import (
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// detectGo finds the main packages of a Go module, each of which becomes a service
+// run with go run from the module root
+func detectGo(root, dir string) ([]Service, error) {
+	if !fileExists(filepath.Join(dir, "go.mod")) {
+		return nil, nil
+	}
+
+	var services []Service
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != dir {
+			// Nested modules are detected on their own
+			if skippedDirs[entry.Name()] || strings.HasPrefix(entry.Name(), ".") || fileExists(filepath.Join(path, "go.mod")) {
+				return filepath.SkipDir
+			}
+		}
+
+		if !isMainPackage(path) {
+			return nil
+		}
+
+		pkg := "."
+		name := dirName(root, dir)
+		if path != dir {
+			pkg = "./" + relative(dir, path)
+			name = serviceName(filepath.Base(path))
+		}
+
+		services = append(services, Service{
+			Name:    name,
+			Path:    relative(root, dir),
+			Command: "go run " + pkg,
+			Source:  relative(root, filepath.Join(dir, "go.mod")),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return services, nil
+}
+
+// isMainPackage reports whether the Go files in dir belong to package main
+func isMainPackage(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.PackageClauseOnly)
+		if err != nil {
+			continue
+		}
+		return file.Name.Name == "main"
+	}
+	return false
+}
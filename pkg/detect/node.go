@@ -0,0 +1,91 @@
+package detect
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// detectNode finds a package.json with a dev or start script
+func detectNode(root, dir string) ([]Service, error) {
+	script, ok := nodeScript(dir)
+	if !ok {
+		return nil, nil
+	}
+
+	return []Service{{
+		Name:    dirName(root, dir),
+		Path:    relative(root, dir),
+		Command: nodeRunCommand(root, dir, script),
+		Source:  relative(root, filepath.Join(dir, "package.json")),
+	}}, nil
+}
+
+// nodeScript returns the script that starts a package for development, preferring dev over start
+func nodeScript(dir string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return "", false
+	}
+
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "", false
+	}
+
+	for _, script := range []string{"dev", "start"} {
+		if _, ok := pkg.Scripts[script]; ok {
+			return script, true
+		}
+	}
+	return "", false
+}
+
+// nodeRunCommand runs a script with the package manager whose lockfile is in the
+// package or, for workspaces, one of its parents
+func nodeRunCommand(root, dir, script string) string {
+	switch findUp(root, dir, "pnpm-lock.yaml", "yarn.lock", "bun.lock", "bun.lockb", "package-lock.json") {
+	case "pnpm-lock.yaml":
+		return "pnpm " + script
+	case "yarn.lock":
+		return "yarn " + script
+	case "bun.lock", "bun.lockb":
+		return "bun run " + script
+	}
+
+	if script == "start" {
+		return "npm start"
+	}
+	return "npm run " + script
+}
+
+// detectWrangler finds Cloudflare Workers whose package.json doesn't already start them
+func detectWrangler(root, dir string) ([]Service, error) {
+	var config string
+	for _, name := range []string{"wrangler.toml", "wrangler.json", "wrangler.jsonc"} {
+		if fileExists(filepath.Join(dir, name)) {
+			config = name
+			break
+		}
+	}
+	if config == "" {
+		return nil, nil
+	}
+	if _, ok := nodeScript(dir); ok {
+		return nil, nil
+	}
+
+	command := "npx wrangler dev"
+	if name := findUp(root, dir, "bun.lock", "bun.lockb"); name != "" {
+		command = "bunx wrangler dev"
+	}
+
+	return []Service{{
+		Name:    dirName(root, dir),
+		Path:    relative(root, dir),
+		Command: command,
+		Source:  relative(root, filepath.Join(dir, config)),
+	}}, nil
+}
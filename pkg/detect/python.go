@@ -0,0 +1,85 @@
+package detect
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/pelletier/go-toml"
+)
+
+// detectPython finds Django projects by their manage.py, and pyproject.toml projects
+// by their scripts or a main.py/app.py entry point
+func detectPython(root, dir string) ([]Service, error) {
+	runner := pythonRunner(root, dir)
+
+	if fileExists(filepath.Join(dir, "manage.py")) {
+		return []Service{{
+			Name:    dirName(root, dir),
+			Path:    relative(root, dir),
+			Command: runner + "python manage.py runserver",
+			Source:  relative(root, filepath.Join(dir, "manage.py")),
+		}}, nil
+	}
+
+	manifest := filepath.Join(dir, "pyproject.toml")
+	if !fileExists(manifest) {
+		return nil, nil
+	}
+	tree, err := toml.LoadFile(manifest)
+	if err != nil {
+		return nil, nil
+	}
+
+	service := Service{
+		Name:   dirName(root, dir),
+		Path:   relative(root, dir),
+		Source: relative(root, manifest),
+	}
+
+	scripts := pythonScripts(tree)
+	if len(scripts) == 1 {
+		service.Command = runner + scripts[0]
+		return []Service{service}, nil
+	}
+	if len(scripts) > 1 {
+		services := make([]Service, 0, len(scripts))
+		for _, script := range scripts {
+			s := service
+			s.Name = serviceName(script)
+			s.Command = runner + script
+			services = append(services, s)
+		}
+		return services, nil
+	}
+
+	for _, entryPoint := range []string{"main.py", "app.py"} {
+		if fileExists(filepath.Join(dir, entryPoint)) {
+			service.Command = runner + "python " + entryPoint
+			return []Service{service}, nil
+		}
+	}
+	return nil, nil
+}
+
+// pythonScripts returns the console scripts a pyproject.toml declares, sorted
+func pythonScripts(tree *toml.Tree) []string {
+	var scripts []string
+	for _, key := range []string{"project.scripts", "tool.poetry.scripts"} {
+		if table, ok := tree.Get(key).(*toml.Tree); ok {
+			scripts = append(scripts, table.Keys()...)
+		}
+	}
+	sort.Strings(scripts)
+	return scripts
+}
+
+// pythonRunner returns the prefix that runs commands in the project's virtualenv
+func pythonRunner(root, dir string) string {
+	switch findUp(root, dir, "uv.lock", "poetry.lock") {
+	case "uv.lock":
+		return "uv run "
+	case "poetry.lock":
+		return "poetry run "
+	}
+	return ""
+}
@@ -0,0 +1,276 @@
+package dockerConnector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/The-Pirateship/runtime/pkg/provider"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// RunsOnPrefix is the runsOn prefix for local containers; "docker.local" is the only value
+const RunsOnPrefix = "docker."
+
+// runsOnLocal deploys to a container on this machine
+const runsOnLocal = RunsOnPrefix + "local"
+
+// imageName is the image instances run; bump the tag when the Dockerfile changes
+const imageName = "runtime-instance:1"
+
+// sshUser is the account CreateInstance authorizes the deploy key for, as on GCP
+const sshUser = "runtime"
+
+// dockerfile builds a stand-in for a GCP instance: Debian 11 booting systemd, with
+// sshd and a runtime user allowed to sudo, so deploys take the same path as in the cloud
+const dockerfile = `FROM debian:11
+ENV container=docker
+RUN apt-get update \
+ && apt-get install -y --no-install-recommends systemd systemd-sysv openssh-server sudo curl ca-certificates \
+ && rm -rf /var/lib/apt/lists/*
+RUN useradd --create-home --shell /bin/bash ` + sshUser + ` \
+ && echo '` + sshUser + ` ALL=(ALL) NOPASSWD:ALL' > /etc/sudoers.d/` + sshUser + ` \
+ && chmod 440 /etc/sudoers.d/` + sshUser + `
+STOPSIGNAL SIGRTMIN+3
+CMD ["/sbin/init"]
+`
+
+// Provider deploys services to containers on this machine, to try runtime deploy
+// without a cloud account. Each container plays the part of an instance: it runs
+// systemd and sshd, and its SSH and service ports are published on localhost.
+type Provider struct{}
+
+// NewProvider returns a Docker provider
+func NewProvider() *Provider {
+	return &Provider{}
+}
+
+func (p *Provider) Name() string {
+	return "Docker"
+}
+
+func (p *Provider) CheckRunsOn(runsOn string) error {
+	if runsOn != runsOnLocal {
+		return fmt.Errorf("'%s' is not supported, only '%s' is", runsOn, runsOnLocal)
+	}
+	return nil
+}
+
+func (p *Provider) Validate(ctx context.Context, config utils.Config) error {
+	if _, err := docker(ctx, "info", "--format", "{{.ServerVersion}}"); err != nil {
+		return fmt.Errorf("docker isn't available; install it and start the daemon: %w", err)
+	}
+	return nil
+}
+
+// EnsureNetwork has nothing to do: CreateInstance publishes each service's ports
+func (p *Provider) EnsureNetwork(ctx context.Context, config utils.Config, ports []int) error {
+	return nil
+}
+
+func (p *Provider) GetInstance(ctx context.Context, config utils.Config, service utils.Service) (provider.Instance, error) {
+	name := containerName(config, service)
+
+	// Prints like "true runtime-instance:1 22/tcp 8000/tcp"
+	output, err := docker(ctx, "inspect", "--format",
+		"{{.State.Running}} {{.Config.Image}}{{range $port, $_ := .HostConfig.PortBindings}} {{$port}}{{end}}", name)
+	if err != nil {
+		if strings.Contains(err.Error(), "No such") {
+			return provider.Instance{}, provider.ErrInstanceNotFound
+		}
+		return provider.Instance{}, fmt.Errorf("failed to look up container: %w", err)
+	}
+	fields := strings.Fields(output)
+	if len(fields) < 2 {
+		return provider.Instance{}, fmt.Errorf("unexpected reply when looking up container: %q", output)
+	}
+
+	instance := provider.Instance{Name: name, User: sshUser, Stopped: fields[0] != "true", Disposable: true}
+	if fields[1] != imageName {
+		instance.Changes = append(instance.Changes, fmt.Sprintf("image %s → %s", fields[1], imageName))
+	}
+	if published, wanted := describePorts(fields[2:]), describeServicePorts(service); published != wanted {
+		instance.Changes = append(instance.Changes, fmt.Sprintf("published ports %s → %s", published, wanted))
+	}
+	if instance.Stopped {
+		return instance, nil
+	}
+
+	if instance.Address, instance.Port, err = publishedPort(ctx, name, 22); err != nil {
+		return provider.Instance{}, err
+	}
+	return instance, nil
+}
+
+func (p *Provider) StartInstance(ctx context.Context, config utils.Config, service utils.Service) (provider.Instance, error) {
+	name := containerName(config, service)
+	fmt.Printf("   ▶️  Starting stopped container %s...\n", name)
+
+	if _, err := docker(ctx, "start", name); err != nil {
+		return provider.Instance{}, fmt.Errorf("failed to start container: %w", err)
+	}
+
+	// Docker picks new host ports on every start
+	instance, err := p.GetInstance(ctx, config, service)
+	if err != nil {
+		return provider.Instance{}, err
+	}
+	printPublishedPorts(ctx, name, service)
+	return instance, nil
+}
+
+func (p *Provider) CreateInstance(ctx context.Context, config utils.Config, service utils.Service, sshKey string) (provider.Instance, error) {
+	if err := ensureImage(ctx); err != nil {
+		return provider.Instance{}, err
+	}
+
+	name := containerName(config, service)
+	fmt.Printf("   🐳 Starting container %s...\n", name)
+
+	// systemd needs the host's cgroups and a writable /run to boot inside the container
+	args := []string{"run", "--detach", "--name", name, "--hostname", name,
+		"--label", "runtime.project=" + config.Name,
+		"--label", "runtime.service=" + service.Name,
+		"--privileged", "--cgroupns=host",
+		"--volume", "/sys/fs/cgroup:/sys/fs/cgroup:rw",
+		"--tmpfs", "/run", "--tmpfs", "/run/lock",
+		"--publish", "127.0.0.1::22",
+	}
+	for _, port := range servicePorts(service) {
+		args = append(args, "--publish", fmt.Sprintf("127.0.0.1::%d", port))
+	}
+	args = append(args, imageName)
+
+	if _, err := docker(ctx, args...); err != nil {
+		return provider.Instance{}, fmt.Errorf("failed to start container: %w", err)
+	}
+
+	authorize := fmt.Sprintf("mkdir -p /home/%[1]s/.ssh && cat > /home/%[1]s/.ssh/authorized_keys && chmod 700 /home/%[1]s/.ssh && chmod 600 /home/%[1]s/.ssh/authorized_keys && chown -R %[1]s:%[1]s /home/%[1]s/.ssh", sshUser)
+	cmd := exec.CommandContext(ctx, "docker", "exec", "--interactive", name, "sh", "-c", authorize)
+	cmd.Stdin = strings.NewReader(sshKey + "\n")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return provider.Instance{}, fmt.Errorf("failed to install SSH key: %w\nOutput: %s", err, output)
+	}
+
+	instance, err := p.GetInstance(ctx, config, service)
+	if err != nil {
+		return provider.Instance{}, err
+	}
+
+	printPublishedPorts(ctx, name, service)
+	return instance, nil
+}
+
+func (p *Provider) DeleteInstance(ctx context.Context, config utils.Config, service utils.Service) error {
+	if _, err := docker(ctx, "rm", "--force", containerName(config, service)); err != nil {
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
+	return nil
+}
+
+// containerName matches the instance names used on GCP
+func containerName(config utils.Config, service utils.Service) string {
+	return fmt.Sprintf("runtime-%s-%s", config.Name, service.Name)
+}
+
+// servicePorts are the ports a deployed service listens on
+func servicePorts(service utils.Service) []int {
+	ports := append([]int{}, service.Ports...)
+	if service.AutoPort {
+		ports = append(ports, utils.DeployedAutoPort)
+	}
+	return ports
+}
+
+// printPublishedPorts shows where the service's ports can be reached on this machine
+func printPublishedPorts(ctx context.Context, name string, service utils.Service) {
+	for _, port := range servicePorts(service) {
+		if host, published, err := publishedPort(ctx, name, port); err == nil {
+			fmt.Printf("   🔌 Port %d is published on %s\n", port, net.JoinHostPort(host, strconv.Itoa(published)))
+		}
+	}
+}
+
+// describePorts lists the service ports among a container's published ports, like
+// "8000, 8080" or "none", leaving out SSH
+func describePorts(published []string) string {
+	var ports []int
+	for _, p := range published {
+		port, err := strconv.Atoi(strings.TrimSuffix(p, "/tcp"))
+		if err == nil && port != 22 {
+			ports = append(ports, port)
+		}
+	}
+	return joinPorts(ports)
+}
+
+// describeServicePorts lists the ports CreateInstance publishes for a service, like describePorts
+func describeServicePorts(service utils.Service) string {
+	return joinPorts(servicePorts(service))
+}
+
+func joinPorts(ports []int) string {
+	if len(ports) == 0 {
+		return "none"
+	}
+	sort.Ints(ports)
+	described := make([]string, len(ports))
+	for i, port := range ports {
+		described[i] = strconv.Itoa(port)
+	}
+	return strings.Join(described, ", ")
+}
+
+// ensureImage builds the instance image unless it already exists
+func ensureImage(ctx context.Context) error {
+	if _, err := docker(ctx, "image", "inspect", imageName); err == nil {
+		return nil
+	}
+
+	fmt.Printf("   🐳 Building %s (first deploy only)...\n", imageName)
+	cmd := exec.CommandContext(ctx, "docker", "build", "--tag", imageName, "-")
+	cmd.Stdin = strings.NewReader(dockerfile)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to build %s: %w\nOutput: %s", imageName, err, output)
+	}
+	return nil
+}
+
+// publishedPort returns where a container port is reachable on this machine
+func publishedPort(ctx context.Context, name string, port int) (string, int, error) {
+	output, err := docker(ctx, "port", name, fmt.Sprintf("%d/tcp", port))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to find published port %d: %w", port, err)
+	}
+
+	// One line per address, like "127.0.0.1:49153"
+	first := strings.SplitN(output, "\n", 2)[0]
+	host, published, err := net.SplitHostPort(first)
+	if err != nil {
+		return "", 0, fmt.Errorf("unexpected published port %q", first)
+	}
+	publishedNumber, err := strconv.Atoi(published)
+	if err != nil {
+		return "", 0, fmt.Errorf("unexpected published port %q", first)
+	}
+
+	return host, publishedNumber, nil
+}
+
+// docker runs a docker command and returns its trimmed output
+func docker(ctx context.Context, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, "docker", args...).CombinedOutput()
+	if err != nil {
+		message := strings.TrimSpace(string(output))
+		if message == "" {
+			return "", err
+		}
+		return "", errors.New(message)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
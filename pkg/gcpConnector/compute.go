@@ -3,36 +3,41 @@ package gcpConnector
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"google.golang.org/api/compute/v1"
 )
 
 type InstanceConfig struct {
-	Name      string
-	Zone      string
-	ProjectID string
-	SSHKey    string // Public SSH key to add
+	Name        string
+	Zone        string
+	ProjectID   string
+	SSHKey      string // Public SSH key to add
+	MachineType string // like "e2-micro"
+	Image       string // full image path, like "projects/debian-cloud/global/images/family/debian-11"
+	DiskSizeGb  int64
+	DiskType    string // like "pd-standard"
 }
 
-// CreateInstance creates an e2-micro instance
+// CreateInstance creates an instance and waits for it to be ready
 func CreateInstance(ctx context.Context, service *compute.Service, cfg InstanceConfig) (*compute.Instance, error) {
-	fmt.Printf("   🔧 Creating instance '%s' in zone '%s'...\n", cfg.Name, cfg.Zone)
+	fmt.Printf("   🔧 Creating %s instance '%s' in zone '%s'...\n", cfg.MachineType, cfg.Name, cfg.Zone)
 
 	// Define the instance specification
 	instance := &compute.Instance{
 		Name:        cfg.Name,
-		MachineType: fmt.Sprintf("zones/%s/machineTypes/e2-micro", cfg.Zone),
+		MachineType: fmt.Sprintf("zones/%s/machineTypes/%s", cfg.Zone, cfg.MachineType),
 
-		// Boot disk with Debian 11
+		// Boot disk
 		Disks: []*compute.AttachedDisk{
 			{
 				Boot:       true,
 				AutoDelete: true,
 				InitializeParams: &compute.AttachedDiskInitializeParams{
-					SourceImage: "projects/debian-cloud/global/images/family/debian-11",
-					DiskSizeGb:  10,
-					DiskType:    fmt.Sprintf("zones/%s/diskTypes/pd-standard", cfg.Zone),
+					SourceImage: cfg.Image,
+					DiskSizeGb:  cfg.DiskSizeGb,
+					DiskType:    fmt.Sprintf("zones/%s/diskTypes/%s", cfg.Zone, cfg.DiskType),
 				},
 			},
 		},
@@ -57,6 +62,11 @@ func CreateInstance(ctx context.Context, service *compute.Service, cfg InstanceC
 					Key:   "ssh-keys",
 					Value: stringPtr(fmt.Sprintf("runtime:%s", cfg.SSHKey)),
 				},
+				// Remembered so redeploys can tell when the image setting changed
+				{
+					Key:   imageMetadataKey,
+					Value: stringPtr(cfg.Image),
+				},
 			},
 		},
 
@@ -99,6 +109,80 @@ func GetExternalIP(instance *compute.Instance) string {
 	return ""
 }
 
+// FindInstances looks up the instances with a name in every zone of the project.
+// Names are only unique within a zone, so more than one can come back.
+func FindInstances(ctx context.Context, service *compute.Service, projectID, name string) ([]*compute.Instance, error) {
+	var found []*compute.Instance
+	err := service.Instances.AggregatedList(projectID).Filter(fmt.Sprintf("name = %q", name)).Pages(ctx, func(page *compute.InstanceAggregatedList) error {
+		for _, scoped := range page.Items {
+			for _, instance := range scoped.Instances {
+				if instance.Name == name {
+					found = append(found, instance)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up instance: %w", err)
+	}
+	return found, nil
+}
+
+// StartInstance starts a stopped instance, or resumes a suspended one, and waits for
+// it to boot. An instance still stopping or suspending is waited for first.
+func StartInstance(ctx context.Context, service *compute.Service, projectID, zone, name string) error {
+	instance, err := waitForSettled(ctx, service, projectID, zone, name)
+	if err != nil {
+		return err
+	}
+
+	var op *compute.Operation
+	switch instance.Status {
+	case "SUSPENDED":
+		fmt.Printf("   ▶️  Resuming suspended instance '%s'...\n", name)
+		if op, err = service.Instances.Resume(projectID, zone, name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to resume instance: %w", err)
+		}
+	case "TERMINATED", "STOPPED":
+		fmt.Printf("   ▶️  Starting stopped instance '%s'...\n", name)
+		if op, err = service.Instances.Start(projectID, zone, name).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to start instance: %w", err)
+		}
+	default:
+		return nil // already running or booting
+	}
+
+	return waitForOperation(ctx, service, projectID, zone, op.Name)
+}
+
+// waitForSettled polls an instance until it is no longer stopping or suspending,
+// since neither a start nor a resume is accepted until then
+func waitForSettled(ctx context.Context, service *compute.Service, projectID, zone, name string) (*compute.Instance, error) {
+	waiting := false
+	for {
+		instance, err := service.Instances.Get(projectID, zone, name).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get instance status: %w", err)
+		}
+		if instance.Status != "STOPPING" && instance.Status != "SUSPENDING" {
+			return instance, nil
+		}
+
+		if !waiting {
+			fmt.Printf("   ⏳ Waiting for instance '%s' to finish %s...\n", name, strings.ToLower(instance.Status))
+			waiting = true
+		}
+
+		// Poll every 2 seconds
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
 // DeleteInstance removes an instance
 func DeleteInstance(ctx context.Context, service *compute.Service, projectID, zone, name string) error {
 	fmt.Printf("   🗑️  Deleting instance '%s'...\n", name)
@@ -0,0 +1,270 @@
+package gcpConnector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"google.golang.org/api/compute/v1"
+)
+
+// Defaults for the GCP settings runtime.toml leaves out
+const (
+	defaultZone       = "us-central1-a"
+	defaultImage      = "projects/debian-cloud/global/images/family/debian-11"
+	defaultDiskSizeGb = 10
+	defaultDiskType   = "pd-standard"
+)
+
+// imageMetadataKey is the instance metadata holding the image it was created from,
+// since a boot disk only records the exact image a family resolved to
+const imageMetadataKey = "runtime-image"
+
+// machineTypePattern matches GCP machine type names, like e2-micro or n2-custom-4-8192
+var machineTypePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*[a-z0-9]$`)
+
+// instanceSpec is everything CreateInstance needs to know about a service's instance
+type instanceSpec struct {
+	Zone        string
+	MachineType string
+	Image       string // full image path
+	DiskSizeGb  int64
+	DiskType    string
+}
+
+// resolveSpecs works out where and on what each GCP service runs. Zones and machine
+// types are checked against the Compute API, so a typo fails the deploy before
+// anything is created.
+func (p *Provider) resolveSpecs(ctx context.Context, config utils.Config) error {
+	fmt.Println("🔍 Checking zones and machine types...")
+
+	zones, err := listZones(ctx, p.compute, config.Name)
+	if err != nil {
+		return err
+	}
+	machineTypes := map[string][]string{} // by zone, listed once each
+
+	p.specs = map[string]instanceSpec{}
+	for _, service := range config.Services {
+		if !strings.HasPrefix(service.RunsOn, RunsOnPrefix) {
+			continue
+		}
+
+		zone, err := pickZone(zones, service.GCP)
+		if err != nil {
+			return fmt.Errorf("service '%s': %w", service.Name, err)
+		}
+
+		if _, ok := machineTypes[zone]; !ok {
+			if machineTypes[zone], err = listMachineTypes(ctx, p.compute, config.Name, zone); err != nil {
+				return err
+			}
+		}
+		machineType := strings.TrimPrefix(service.RunsOn, RunsOnPrefix)
+		if err := checkMachineType(machineType, zone, machineTypes[zone]); err != nil {
+			return fmt.Errorf("service '%s': %w", service.Name, err)
+		}
+
+		p.specs[service.Name] = newInstanceSpec(zone, machineType, service.GCP)
+	}
+
+	fmt.Print("✅ Zones and machine types are available\n\n")
+	return nil
+}
+
+// checkMachineType checks a machine type is among those offered in a zone. Custom
+// machine types, like n2-custom-4-8192, aren't listed; Insert checks them.
+func checkMachineType(machineType, zone string, available []string) error {
+	if containsString(available, machineType) || strings.Contains(machineType, "-custom-") {
+		return nil
+	}
+	return fmt.Errorf("machine type '%s' is not available in zone %s%s", machineType, zone, didYouMean(machineType, available))
+}
+
+// newInstanceSpec fills in the instance spec for a zone and machine type from the
+// service's GCP settings, using the defaults for what they leave out
+func newInstanceSpec(zone, machineType string, settings utils.GCPSettings) instanceSpec {
+	spec := instanceSpec{
+		Zone:        zone,
+		MachineType: machineType,
+		Image:       sourceImage(settings.Image),
+		DiskSizeGb:  defaultDiskSizeGb,
+		DiskType:    defaultDiskType,
+	}
+	if settings.DiskSizeGb != 0 {
+		spec.DiskSizeGb = int64(settings.DiskSizeGb)
+	}
+	if settings.DiskType != "" {
+		spec.DiskType = settings.DiskType
+	}
+	return spec
+}
+
+// spec returns the service's resolved instance spec
+func (p *Provider) spec(service utils.Service) instanceSpec {
+	if spec, ok := p.specs[service.Name]; ok {
+		return spec
+	}
+
+	// Not resolved yet; enough to find an existing instance
+	zone := service.GCP.Zone
+	if zone == "" {
+		zone = defaultZone
+	}
+	return instanceSpec{Zone: zone}
+}
+
+// instanceChanges lists how an existing instance in zone differs from its spec, besides the zone
+func (p *Provider) instanceChanges(ctx context.Context, config utils.Config, instance *compute.Instance, zone string, spec instanceSpec) ([]string, error) {
+	var changes []string
+
+	if current := path.Base(instance.MachineType); current != spec.MachineType {
+		changes = append(changes, fmt.Sprintf("machine type %s → %s", current, spec.MachineType))
+	}
+
+	if instance.Metadata != nil {
+		for _, item := range instance.Metadata.Items {
+			if item.Key == imageMetadataKey && item.Value != nil && *item.Value != spec.Image {
+				changes = append(changes, fmt.Sprintf("image %s → %s", path.Base(*item.Value), path.Base(spec.Image)))
+			}
+		}
+	}
+
+	for _, attached := range instance.Disks {
+		if !attached.Boot {
+			continue
+		}
+
+		disk, err := p.compute.Disks.Get(config.Name, zone, path.Base(attached.Source)).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up boot disk: %w", err)
+		}
+		if disk.SizeGb != spec.DiskSizeGb {
+			changes = append(changes, fmt.Sprintf("disk size %d GB → %d GB", disk.SizeGb, spec.DiskSizeGb))
+		}
+		if current := path.Base(disk.Type); current != spec.DiskType {
+			changes = append(changes, fmt.Sprintf("disk type %s → %s", current, spec.DiskType))
+		}
+	}
+
+	return changes, nil
+}
+
+// listZones returns the project's zones by name
+func listZones(ctx context.Context, service *compute.Service, projectID string) (map[string]*compute.Zone, error) {
+	zones := map[string]*compute.Zone{}
+	err := service.Zones.List(projectID).Pages(ctx, func(page *compute.ZoneList) error {
+		for _, zone := range page.Items {
+			zones[zone.Name] = zone
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list zones: %w", err)
+	}
+	return zones, nil
+}
+
+// listMachineTypes returns the names of the machine types offered in a zone
+func listMachineTypes(ctx context.Context, service *compute.Service, projectID, zone string) ([]string, error) {
+	var names []string
+	err := service.MachineTypes.List(projectID, zone).Pages(ctx, func(page *compute.MachineTypeList) error {
+		for _, machineType := range page.Items {
+			names = append(names, machineType.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machine types in %s: %w", zone, err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// pickZone returns the zone set in settings, the first available zone of its
+// region, or the default zone
+func pickZone(zones map[string]*compute.Zone, settings utils.GCPSettings) (string, error) {
+	zone := settings.Zone
+	if zone == "" && settings.Region != "" {
+		return zoneInRegion(zones, settings.Region)
+	}
+	if zone == "" {
+		zone = defaultZone
+	}
+
+	z, ok := zones[zone]
+	if !ok {
+		names := make([]string, 0, len(zones))
+		for name := range zones {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("zone '%s' doesn't exist%s", zone, didYouMean(zone, names))
+	}
+	if z.Status != "UP" {
+		return "", fmt.Errorf("zone '%s' is %s", zone, strings.ToLower(z.Status))
+	}
+	return zone, nil
+}
+
+// zoneInRegion returns the first of a region's zones that is up, by name
+func zoneInRegion(zones map[string]*compute.Zone, region string) (string, error) {
+	var regions, available []string
+	for name, z := range zones {
+		zoneRegion := path.Base(z.Region)
+		if !containsString(regions, zoneRegion) {
+			regions = append(regions, zoneRegion)
+		}
+		if zoneRegion == region && z.Status == "UP" {
+			available = append(available, name)
+		}
+	}
+
+	if len(available) == 0 {
+		if containsString(regions, region) {
+			return "", fmt.Errorf("region '%s' has no zone available right now", region)
+		}
+		sort.Strings(regions)
+		return "", fmt.Errorf("region '%s' doesn't exist%s", region, didYouMean(region, regions))
+	}
+
+	sort.Strings(available)
+	return available[0], nil
+}
+
+// sourceImage turns the image setting into a full image path. A bare family is
+// looked up in debian-cloud and "project/family" in that project.
+func sourceImage(image string) string {
+	switch {
+	case image == "":
+		return defaultImage
+	case strings.HasPrefix(image, "projects/") || strings.HasPrefix(image, "https://"):
+		return image
+	case strings.Contains(image, "/"):
+		project, family, _ := strings.Cut(image, "/")
+		return fmt.Sprintf("projects/%s/global/images/family/%s", project, family)
+	default:
+		return fmt.Sprintf("projects/debian-cloud/global/images/family/%s", image)
+	}
+}
+
+// didYouMean suggests the closest candidate for an error message, if one is close
+func didYouMean(name string, candidates []string) string {
+	if suggestion, ok := utils.ClosestMatch(name, candidates); ok {
+		return fmt.Sprintf(", did you mean '%s'?", suggestion)
+	}
+	return ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
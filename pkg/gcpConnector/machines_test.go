@@ -0,0 +1,110 @@
+package gcpConnector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"google.golang.org/api/compute/v1"
+)
+
+func testZones() map[string]*compute.Zone {
+	regions := "https://www.googleapis.com/compute/v1/projects/shop/regions/"
+	return map[string]*compute.Zone{
+		"us-central1-a":   {Name: "us-central1-a", Region: regions + "us-central1", Status: "UP"},
+		"us-central1-b":   {Name: "us-central1-b", Region: regions + "us-central1", Status: "UP"},
+		"europe-west1-c":  {Name: "europe-west1-c", Region: regions + "europe-west1", Status: "UP"},
+		"europe-west1-b":  {Name: "europe-west1-b", Region: regions + "europe-west1", Status: "UP"},
+		"europe-west1-d":  {Name: "europe-west1-d", Region: regions + "europe-west1", Status: "DOWN"},
+		"asia-east1-a":    {Name: "asia-east1-a", Region: regions + "asia-east1", Status: "DOWN"},
+		"southamerica-x1": {Name: "southamerica-x1", Region: regions + "southamerica-east1", Status: "DOWN"},
+	}
+}
+
+func TestPickZone(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings utils.GCPSettings
+		want     string
+		err      string
+	}{
+		{name: "default", want: "us-central1-a"},
+		{name: "zone", settings: utils.GCPSettings{Zone: "us-central1-b"}, want: "us-central1-b"},
+		{name: "zone wins over region", settings: utils.GCPSettings{Region: "europe-west1", Zone: "us-central1-b"}, want: "us-central1-b"},
+		{name: "first zone of region that is up", settings: utils.GCPSettings{Region: "europe-west1"}, want: "europe-west1-b"},
+		{name: "region without zones up", settings: utils.GCPSettings{Region: "asia-east1"}, err: "region 'asia-east1' has no zone available right now"},
+		{name: "misspelt region", settings: utils.GCPSettings{Region: "europe-wset1"}, err: "region 'europe-wset1' doesn't exist, did you mean 'europe-west1'?"},
+		{name: "misspelt zone", settings: utils.GCPSettings{Zone: "us-central1-z"}, err: "zone 'us-central1-z' doesn't exist, did you mean"},
+		{name: "zone down", settings: utils.GCPSettings{Zone: "europe-west1-d"}, err: "zone 'europe-west1-d' is down"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			zone, err := pickZone(testZones(), tc.settings)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got %q, %v, want an error containing %q", zone, err, tc.err)
+				}
+				return
+			}
+			if err != nil || zone != tc.want {
+				t.Errorf("got %q, %v, want %q", zone, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestSourceImage(t *testing.T) {
+	tests := map[string]string{
+		"":                                "projects/debian-cloud/global/images/family/debian-11",
+		"debian-12":                       "projects/debian-cloud/global/images/family/debian-12",
+		"ubuntu-os-cloud/ubuntu-2204-lts": "projects/ubuntu-os-cloud/global/images/family/ubuntu-2204-lts",
+		"projects/my-project/global/images/my-image":                                                             "projects/my-project/global/images/my-image",
+		"projects/cos-cloud/global/images/family/cos-stable":                                                     "projects/cos-cloud/global/images/family/cos-stable",
+		"https://www.googleapis.com/compute/v1/projects/debian-cloud/global/images/debian-12-bookworm-v20240110": "https://www.googleapis.com/compute/v1/projects/debian-cloud/global/images/debian-12-bookworm-v20240110",
+	}
+
+	for image, want := range tests {
+		if got := sourceImage(image); got != want {
+			t.Errorf("sourceImage(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestCheckMachineType(t *testing.T) {
+	available := []string{"e2-medium", "e2-micro", "e2-small", "n2-standard-4"}
+
+	tests := []struct {
+		machineType string
+		err         string
+	}{
+		{machineType: "e2-micro"},
+		{machineType: "n2-custom-4-8192"},
+		{machineType: "e2-mirco", err: "machine type 'e2-mirco' is not available in zone us-central1-a, did you mean 'e2-micro'?"},
+		{machineType: "m3-ultramem-128", err: "machine type 'm3-ultramem-128' is not available in zone us-central1-a"},
+	}
+
+	for _, tc := range tests {
+		err := checkMachineType(tc.machineType, "us-central1-a", available)
+		if tc.err == "" && err != nil {
+			t.Errorf("%s: got %v, want it accepted", tc.machineType, err)
+		}
+		if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%s: got %v, want an error containing %q", tc.machineType, err, tc.err)
+		}
+	}
+}
+
+func TestNewInstanceSpec(t *testing.T) {
+	spec := newInstanceSpec("us-central1-a", "e2-micro", utils.GCPSettings{})
+	want := instanceSpec{Zone: "us-central1-a", MachineType: "e2-micro", Image: defaultImage, DiskSizeGb: 10, DiskType: "pd-standard"}
+	if spec != want {
+		t.Errorf("defaults gave %+v, want %+v", spec, want)
+	}
+
+	spec = newInstanceSpec("europe-west1-b", "e2-small", utils.GCPSettings{Image: "debian-12", DiskSizeGb: 30, DiskType: "pd-ssd"})
+	want = instanceSpec{Zone: "europe-west1-b", MachineType: "e2-small", Image: "projects/debian-cloud/global/images/family/debian-12", DiskSizeGb: 30, DiskType: "pd-ssd"}
+	if spec != want {
+		t.Errorf("settings gave %+v, want %+v", spec, want)
+	}
+}
@@ -3,13 +3,15 @@ package gcpConnector
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"google.golang.org/api/compute/v1"
 )
 
-// EnsureFirewallRules creates necessary firewall rules if they don't exist
-func EnsureFirewallRules(ctx context.Context, service *compute.Service, projectID string) error {
+// EnsureFirewallRules creates necessary firewall rules if they don't exist, opening
+// SSH and the ports the project's services declare
+func EnsureFirewallRules(ctx context.Context, service *compute.Service, projectID string, ports []int) error {
 	fmt.Println("🔒 Checking firewall rules...")
 
 	// Rule 1: Allow SSH (port 22)
@@ -29,32 +31,51 @@ func EnsureFirewallRules(ctx context.Context, service *compute.Service, projectI
 		return err
 	}
 
-	// Rule 2: Allow HTTP traffic (common ports)
+	// Rule 2: Allow traffic to the ports services listen on
+	if len(ports) == 0 {
+		fmt.Println("ℹ️  No service declares a port in runtime.toml, so only SSH is open")
+		fmt.Print("✅ Firewall rules configured\n\n")
+		return nil
+	}
+
+	allowedPorts := make([]string, 0, len(ports))
+	for _, port := range ports {
+		allowedPorts = append(allowedPorts, strconv.Itoa(port))
+	}
+
 	if err := ensureFirewallRule(ctx, service, projectID, &compute.Firewall{
 		Name:    "runtime-allow-http",
 		Network: "global/networks/default",
 		Allowed: []*compute.FirewallAllowed{
 			{
 				IPProtocol: "tcp",
-				Ports:      []string{"80", "443", "3000", "8000", "8080"},
+				Ports:      allowedPorts,
 			},
 		},
 		SourceRanges: []string{"0.0.0.0/0"},
 		TargetTags:   []string{"runtime-instance"},
-		Description:  "Allow HTTP traffic to Runtime instances",
+		Description:  "Allow traffic to the ports Runtime services listen on",
 	}); err != nil {
 		return err
 	}
 
-	fmt.Println("✅ Firewall rules configured\n")
+	fmt.Print("✅ Firewall rules configured\n\n")
 	return nil
 }
 
 func ensureFirewallRule(ctx context.Context, service *compute.Service, projectID string, rule *compute.Firewall) error {
 	// Check if rule already exists
-	_, err := service.Firewalls.Get(projectID, rule.Name).Context(ctx).Do()
+	existing, err := service.Firewalls.Get(projectID, rule.Name).Context(ctx).Do()
 	if err == nil {
-		// Rule already exists
+		if sameAllowed(existing.Allowed, rule.Allowed) {
+			return nil
+		}
+
+		// The declared ports changed since the rule was created
+		if _, err := service.Firewalls.Patch(projectID, rule.Name, rule).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to update firewall rule '%s': %w", rule.Name, err)
+		}
+		fmt.Printf("   🔓 Updated '%s' to allow ports %s\n", rule.Name, strings.Join(rule.Allowed[0].Ports, ", "))
 		return nil
 	}
 
@@ -67,6 +88,19 @@ func ensureFirewallRule(ctx context.Context, service *compute.Service, projectID
 	return nil
 }
 
+// sameAllowed reports whether two firewall rules allow the same protocols and ports
+func sameAllowed(a, b []*compute.FirewallAllowed) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].IPProtocol != b[i].IPProtocol || strings.Join(a[i].Ports, ",") != strings.Join(b[i].Ports, ",") {
+			return false
+		}
+	}
+	return true
+}
+
 func isAlreadyExistsError(err error) bool {
 	return strings.Contains(err.Error(), "alreadyExists")
 }
@@ -0,0 +1,171 @@
+package gcpConnector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/The-Pirateship/runtime/pkg/provider"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"google.golang.org/api/compute/v1"
+)
+
+// RunsOnPrefix is the runsOn prefix for services deployed to GCP, followed by the
+// machine type, as in "gcp.e2-micro"
+const RunsOnPrefix = "gcp."
+
+// sshUser is the account CreateInstance authorizes the deploy key for
+const sshUser = "runtime"
+
+// stoppedStatuses are the instance statuses StartInstance brings back up; an instance
+// still stopping or suspending is waited for first
+var stoppedStatuses = map[string]bool{
+	"STOPPING":   true,
+	"STOPPED":    true,
+	"TERMINATED": true,
+	"SUSPENDING": true,
+	"SUSPENDED":  true,
+}
+
+// Provider deploys services to Compute Engine instances. The project's name in
+// runtime.toml is used as the GCP project ID.
+type Provider struct {
+	compute *compute.Service
+	specs   map[string]instanceSpec // by service name, resolved in Validate
+	zones   map[string]string       // by service name, where GetInstance found the instance
+}
+
+// NewProvider returns a GCP provider; it connects to GCP in Validate
+func NewProvider() *Provider {
+	return &Provider{zones: map[string]string{}}
+}
+
+func (p *Provider) Name() string {
+	return "GCP"
+}
+
+func (p *Provider) CheckRunsOn(runsOn string) error {
+	if !machineTypePattern.MatchString(strings.TrimPrefix(runsOn, RunsOnPrefix)) {
+		return fmt.Errorf("'%s' is not a GCP machine type, expected something like 'gcp.e2-micro' or 'gcp.e2-standard-4'", runsOn)
+	}
+	return nil
+}
+
+func (p *Provider) Validate(ctx context.Context, config utils.Config) error {
+	if err := ValidateProject(ctx, config.Name); err != nil {
+		return err
+	}
+
+	fmt.Println("🔐 Authenticating with GCP...")
+	service, err := GetComputeService(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Print("✅ Authenticated successfully\n\n")
+
+	p.compute = service
+	return p.resolveSpecs(ctx, config)
+}
+
+func (p *Provider) EnsureNetwork(ctx context.Context, config utils.Config, ports []int) error {
+	return EnsureFirewallRules(ctx, p.compute, config.Name, ports)
+}
+
+// GetInstance finds the service's instance in any zone, so one left behind by a zone
+// change is reported as a change rather than missed
+func (p *Provider) GetInstance(ctx context.Context, config utils.Config, service utils.Service) (provider.Instance, error) {
+	name := instanceName(config, service)
+	found, err := FindInstances(ctx, p.compute, config.Name, name)
+	if err != nil {
+		return provider.Instance{}, err
+	}
+	switch {
+	case len(found) == 0:
+		return provider.Instance{}, provider.ErrInstanceNotFound
+	case len(found) > 1:
+		zones := make([]string, len(found))
+		for i, instance := range found {
+			zones[i] = path.Base(instance.Zone)
+		}
+		return provider.Instance{}, fmt.Errorf("found an instance named '%s' in each of zones %s; delete the ones you no longer need", name, strings.Join(zones, ", "))
+	}
+
+	instance := found[0]
+	zone := path.Base(instance.Zone)
+	p.zones[service.Name] = zone
+
+	result := toInstance(instance)
+	result.Stopped = stoppedStatuses[instance.Status]
+	if spec, ok := p.specs[service.Name]; ok {
+		if zone != spec.Zone {
+			result.Changes = append(result.Changes, fmt.Sprintf("zone %s → %s", zone, spec.Zone))
+		}
+		changes, err := p.instanceChanges(ctx, config, instance, zone, spec)
+		if err != nil {
+			return provider.Instance{}, err
+		}
+		result.Changes = append(result.Changes, changes...)
+	}
+
+	return result, nil
+}
+
+func (p *Provider) StartInstance(ctx context.Context, config utils.Config, service utils.Service) (provider.Instance, error) {
+	if err := StartInstance(ctx, p.compute, config.Name, p.zone(service), instanceName(config, service)); err != nil {
+		return provider.Instance{}, err
+	}
+
+	// The external IP can change across a stop and start
+	return p.GetInstance(ctx, config, service)
+}
+
+func (p *Provider) CreateInstance(ctx context.Context, config utils.Config, service utils.Service, sshKey string) (provider.Instance, error) {
+	spec := p.spec(service)
+	instance, err := CreateInstance(ctx, p.compute, InstanceConfig{
+		Name:        instanceName(config, service),
+		Zone:        spec.Zone,
+		ProjectID:   config.Name,
+		SSHKey:      sshKey,
+		MachineType: spec.MachineType,
+		Image:       spec.Image,
+		DiskSizeGb:  spec.DiskSizeGb,
+		DiskType:    spec.DiskType,
+	})
+	if err != nil {
+		return provider.Instance{}, err
+	}
+
+	return toInstance(instance), nil
+}
+
+func (p *Provider) DeleteInstance(ctx context.Context, config utils.Config, service utils.Service) error {
+	if err := DeleteInstance(ctx, p.compute, config.Name, p.zone(service), instanceName(config, service)); err != nil {
+		return err
+	}
+	delete(p.zones, service.Name)
+	return nil
+}
+
+// zone returns where the service's instance is: where GetInstance found it, or else
+// where CreateInstance puts it
+func (p *Provider) zone(service utils.Service) string {
+	if zone, ok := p.zones[service.Name]; ok {
+		return zone
+	}
+	return p.spec(service).Zone
+}
+
+// instanceName is the name of a service's instance, unique within the GCP project
+func instanceName(config utils.Config, service utils.Service) string {
+	return fmt.Sprintf("runtime-%s-%s", config.Name, service.Name)
+}
+
+func toInstance(instance *compute.Instance) provider.Instance {
+	return provider.Instance{
+		Name:       instance.Name,
+		Address:    GetExternalIP(instance),
+		User:       sshUser,
+		Disposable: true,
+	}
+}
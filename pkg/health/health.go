@@ -0,0 +1,118 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// Check runs a single attempt of the health check. dir and env are used for command checks.
+func Check(ctx context.Context, check utils.HealthCheck, dir string, env []string) error {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	switch check.Type {
+	case utils.HealthCheckTCP:
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", check.Target)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+
+	case utils.HealthCheckHTTP:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.Target, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("GET %s returned %s", check.Target, resp.Status)
+		}
+		return nil
+
+	case utils.HealthCheckCommand:
+		cmd := exec.CommandContext(ctx, "sh", "-c", check.Target)
+		cmd.Dir = dir
+		cmd.Env = env
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown health check type '%s'", check.Type)
+}
+
+// Wait retries attempt until it succeeds, the retries run out or ctx is cancelled.
+// onFailure, if set, is called after every failed attempt.
+func Wait(ctx context.Context, check utils.HealthCheck, attempt func(ctx context.Context) error, onFailure func(try int, err error)) error {
+	var lastErr error
+
+	for try := 1; try <= check.Retries+1; try++ {
+		if lastErr = attempt(ctx); lastErr == nil {
+			return nil
+		}
+
+		if onFailure != nil {
+			onFailure(try, lastErr)
+		}
+		if try == check.Retries+1 {
+			break // no point waiting after the last attempt
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(check.Interval):
+		}
+	}
+
+	return fmt.Errorf("health check failed after %d attempts: %w", check.Retries+1, lastErr)
+}
+
+// Describe returns a short human readable form of the check, e.g. "GET http://localhost:3000"
+func Describe(check utils.HealthCheck) string {
+	switch check.Type {
+	case utils.HealthCheckHTTP:
+		return "GET " + check.Target
+	case utils.HealthCheckTCP:
+		return "tcp " + check.Target
+	default:
+		return "`" + check.Target + "`"
+	}
+}
+
+// RemoteCommand returns a shell command that performs one attempt of the check on a
+// remote instance, for a service whose code and environment file live in appDir
+func RemoteCommand(check utils.HealthCheck, appDir string) string {
+	timeout := int(check.Timeout.Seconds())
+	if timeout < 1 {
+		timeout = 1
+	}
+
+	switch check.Type {
+	case utils.HealthCheckTCP:
+		host, port, _ := net.SplitHostPort(check.Target)
+		return fmt.Sprintf("timeout %d bash -c %s", timeout,
+			utils.ShellQuote(fmt.Sprintf("cat < /dev/null > /dev/tcp/%s/%s", host, port)))
+
+	case utils.HealthCheckHTTP:
+		return fmt.Sprintf("code=$(curl -s -o /dev/null -w '%%{http_code}' --max-time %d %s) && [ \"$code\" -ge 200 ] && [ \"$code\" -le 299 ]",
+			timeout, utils.ShellQuote(check.Target))
+
+	default:
+		return fmt.Sprintf("cd %s && set -a && . ./%s && set +a && timeout %d sh -c %s",
+			utils.ShellQuote(appDir), utils.DeployedEnvFile, timeout, utils.ShellQuote(check.Target))
+	}
+}
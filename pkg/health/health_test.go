@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+func TestWait(t *testing.T) {
+	errDown := errors.New("connection refused")
+
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int // attempts that fail before one succeeds
+		wantTries int
+		wantErr   bool
+	}{
+		{name: "first attempt passes", retries: 3, failures: 0, wantTries: 1},
+		{name: "passes on a retry", retries: 3, failures: 2, wantTries: 3},
+		{name: "passes on the last retry", retries: 3, failures: 3, wantTries: 4},
+		{name: "retries run out", retries: 3, failures: 10, wantTries: 4, wantErr: true},
+		{name: "no retries", retries: 0, failures: 10, wantTries: 1, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			check := utils.HealthCheck{Retries: tc.retries, Interval: time.Millisecond}
+
+			tries, reported := 0, 0
+			attempt := func(ctx context.Context) error {
+				tries++
+				if tries <= tc.failures {
+					return errDown
+				}
+				return nil
+			}
+			onFailure := func(try int, err error) {
+				reported++
+				if try != tries || err != errDown {
+					t.Errorf("onFailure(%d, %v) after attempt %d", try, err, tries)
+				}
+			}
+
+			err := Wait(context.Background(), check, attempt, onFailure)
+			if tries != tc.wantTries {
+				t.Errorf("made %d attempts, want %d", tries, tc.wantTries)
+			}
+			if reported != min(tc.failures, tc.wantTries) {
+				t.Errorf("reported %d failures, want %d", reported, min(tc.failures, tc.wantTries))
+			}
+			if tc.wantErr != (err != nil) || (err != nil && !errors.Is(err, errDown)) {
+				t.Errorf("got error %v, want error: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// TestWaitDoesNotSleepAfterLastAttempt checks a failed check is reported right away
+// rather than after one more interval
+func TestWaitDoesNotSleepAfterLastAttempt(t *testing.T) {
+	check := utils.HealthCheck{Retries: 0, Interval: time.Hour}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Wait(context.Background(), check, func(context.Context) error { return errors.New("down") }, nil)
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Wait passed a failing check")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait slept after the last attempt")
+	}
+}
+
+// TestWaitStopsWhenCancelled checks cancelling the context ends the wait between attempts
+func TestWaitStopsWhenCancelled(t *testing.T) {
+	check := utils.HealthCheck{Retries: 5, Interval: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	tries := 0
+	attempt := func(context.Context) error {
+		tries++
+		return errors.New("down")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Wait(ctx, check, attempt, func(int, error) { cancel() })
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+		if tries != 1 {
+			t.Errorf("made %d attempts after cancelling, want 1", tries)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait kept going after the context was cancelled")
+	}
+}
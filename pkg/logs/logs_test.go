@@ -0,0 +1,202 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// bigLine returns a line of which four, with their timestamps, just fit in a log
+// file, so every fifth one rotates it
+func bigLine(label string) string {
+	return label + " " + strings.Repeat("x", MaxSize/4-100-len(label)) + "\n"
+}
+
+// labels returns the first word of each line's text
+func labels(lines []Line) []string {
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i], _, _ = strings.Cut(line.Text, " ")
+	}
+	return result
+}
+
+// TestWriterRotates checks the log rotates before it would pass MaxSize, keeps
+// MaxBackups old files and Read puts the lines back together oldest first
+func TestWriterRotates(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want []string
+	for i := 0; i < 20; i++ {
+		label := fmt.Sprintf("line%d", i)
+		if _, err := w.Write([]byte(bigLine(label))); err != nil {
+			t.Fatal(err)
+		}
+		// The first four files' worth are rotated away, as only three backups are kept
+		if i >= 4 {
+			want = append(want, label)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := Path(dir, "api")
+	for _, file := range []string{path, backupPath(path, 1), backupPath(path, 2), backupPath(path, MaxBackups)} {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > MaxSize {
+			t.Errorf("%s is %d bytes, over the %d byte limit", filepath.Base(file), info.Size(), MaxSize)
+		}
+	}
+	if _, err := os.Stat(backupPath(path, MaxBackups+1)); !os.IsNotExist(err) {
+		t.Errorf("more than %d rotated files were kept", MaxBackups)
+	}
+
+	lines, offset, err := Read(dir, "api", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := labels(lines); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("read back %v, want %v", got, want)
+	}
+
+	info, _ := os.Stat(path)
+	if offset != info.Size() {
+		t.Errorf("Read stopped at %d, want the end of the current file at %d", offset, info.Size())
+	}
+}
+
+// TestWriterHoldsPartialLines checks output split across writes makes a single
+// line, and a trailing partial line is written on Close
+func TestWriterHoldsPartialLines(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, chunk := range []string{"listen", "ing on :3000\r\nready\n", "shutting down"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, _, err := Read(dir, "api", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"listening on :3000", "ready", "shutting down"}
+	if len(lines) != len(want) {
+		t.Fatalf("read %d lines, want %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		if line.Text != want[i] || line.Time.IsZero() {
+			t.Errorf("line %d is %q at %v, want %q with a timestamp", i, line.Text, line.Time, want[i])
+		}
+	}
+}
+
+// TestReadSince checks older lines, across files, are left out, along with lines
+// whose time is unknown
+func TestReadSince(t *testing.T) {
+	dir := t.TempDir()
+	path := Path(dir, "api")
+	if err := os.MkdirAll(Dir(dir), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		backupPath(path, 1): "2026-01-01T10:00:00.000Z old\n2026-01-01T11:00:00.000Z newer\n",
+		path:                "2026-01-01T12:00:00.000Z newest\nno timestamp\n",
+	}
+	for file, contents := range files {
+		if err := os.WriteFile(file, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	since := time.Date(2026, 1, 1, 10, 30, 0, 0, time.UTC)
+	lines, _, err := Read(dir, "api", since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := labels(lines); strings.Join(got, " ") != "newer newest" {
+		t.Errorf("read %v, want the lines since 10:30", got)
+	}
+}
+
+// TestFollowAcrossRotation checks Follow reads the end of the rotated file and
+// then carries on with the new one, losing no lines
+func TestFollowAcrossRotation(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Open(dir, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("old already read\n"))
+	_, offset, err := Read(dir, "api", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	followed := make(chan string, 100)
+	go Follow(ctx, dir, "api", offset, func(line Line) {
+		label, _, _ := strings.Cut(line.Text, " ")
+		followed <- label
+	})
+
+	next := func() string {
+		select {
+		case label := <-followed:
+			return label
+		case <-time.After(5 * time.Second):
+			t.Fatal("Follow stopped passing on lines")
+			return ""
+		}
+	}
+
+	// Make sure Follow has the first file open before it is rotated
+	w.Write([]byte("before\n"))
+	if got := next(); got != "before" {
+		t.Fatalf("followed %q first, want before", got)
+	}
+
+	var want []string
+	for i := 0; i < 5; i++ {
+		label := fmt.Sprintf("big%d", i)
+		w.Write([]byte(bigLine(label)))
+		want = append(want, label)
+	}
+	w.Write([]byte("after\n"))
+	want = append(want, "after")
+
+	if _, err := os.Stat(backupPath(Path(dir, "api"), 1)); err != nil {
+		t.Fatal("the log wasn't rotated")
+	}
+
+	for _, label := range want {
+		if got := next(); got != label {
+			t.Fatalf("followed %q, want %q", got, label)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Line is one line from a log file
+type Line struct {
+	Time time.Time // zero if the line has no timestamp
+	Text string
+}
+
+// parseLine splits a log file line into its timestamp and text
+func parseLine(raw string) Line {
+	stamp, text, found := strings.Cut(raw, " ")
+	if !found {
+		return Line{Text: raw}
+	}
+
+	t, err := time.Parse(timestampFormat, stamp)
+	if err != nil {
+		return Line{Text: raw}
+	}
+	return Line{Time: t, Text: text}
+}
+
+// Read returns the lines logged for a service since the given time, oldest first,
+// including rotated files. It also returns how far it read into the current log
+// file, for Follow to continue from.
+func Read(projectDir, service string, since time.Time) ([]Line, int64, error) {
+	path := Path(projectDir, service)
+
+	var lines []Line
+	for n := MaxBackups; n >= 1; n-- {
+		data, err := os.ReadFile(backupPath(path, n))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		lines, _ = appendLines(lines, data, since)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, 0, err
+	}
+	lines, consumed := appendLines(lines, data, since)
+
+	return lines, int64(consumed), nil
+}
+
+// appendLines parses every complete line in data, keeping those logged since the
+// given time, and returns how many bytes of data were consumed
+func appendLines(lines []Line, data []byte, since time.Time) ([]Line, int) {
+	consumed := 0
+	for {
+		idx := bytes.IndexByte(data[consumed:], '\n')
+		if idx < 0 {
+			return lines, consumed
+		}
+
+		line := parseLine(string(data[consumed : consumed+idx]))
+		consumed += idx + 1
+
+		if !since.IsZero() && line.Time.Before(since) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+}
+
+// Follow calls fn for every line appended to a service's log file after offset,
+// until ctx is cancelled. It keeps following across rotations.
+func Follow(ctx context.Context, projectDir, service string, offset int64, fn func(Line)) error {
+	path := Path(projectDir, service)
+
+	var file *os.File
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	var pending []byte
+	for {
+		if file == nil {
+			f, err := os.Open(path)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			if f != nil {
+				if _, err := f.Seek(offset, io.SeekStart); err != nil {
+					f.Close()
+					return err
+				}
+				file = f
+			}
+		}
+
+		if file != nil {
+			// Check before reading, so the rest of a rotated file is read before moving on
+			wasRotated := rotated(file, path)
+
+			data, err := io.ReadAll(file)
+			if err != nil {
+				return err
+			}
+			offset += int64(len(data))
+
+			pending = append(pending, data...)
+			lines, consumed := appendLines(nil, pending, time.Time{})
+			pending = pending[consumed:]
+			for _, line := range lines {
+				fn(line)
+			}
+
+			// A rotation replaces the file at path, so start again at the top of the new one
+			if wasRotated {
+				file.Close()
+				file = nil
+				offset = 0
+				pending = nil
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// rotated reports whether path no longer refers to the open file
+func rotated(file *os.File, path string) bool {
+	openInfo, err := file.Stat()
+	if err != nil {
+		return true
+	}
+	pathInfo, err := os.Stat(path)
+	if err != nil {
+		// Between renames there may briefly be no file at all
+		return false
+	}
+	return !os.SameFile(openInfo, pathInfo)
+}
@@ -0,0 +1,153 @@
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxSize is how large a log file grows before it is rotated
+	MaxSize = 10 << 20
+	// MaxBackups is how many rotated files (<service>.log.1, .2, ...) are kept
+	MaxBackups = 3
+
+	// timestampFormat starts every line in a log file, so logs can be filtered by time
+	timestampFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// Dir returns where log files for a project live
+func Dir(projectDir string) string {
+	return filepath.Join(projectDir, ".runtime", "logs")
+}
+
+// Path returns the current log file for a service
+func Path(projectDir, service string) string {
+	return filepath.Join(Dir(projectDir), service+".log")
+}
+
+// backupPath returns the nth rotated log file for a service, 1 being the newest
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// Writer appends a service's output to its log file, one timestamped line at a
+// time, and rotates the file once it reaches MaxSize. It is safe for concurrent use.
+type Writer struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	buf  []byte
+}
+
+// Open opens the log file for a service for appending, creating it if needed
+func Open(projectDir, service string) (*Writer, error) {
+	if err := os.MkdirAll(Dir(projectDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	w := &Writer{path: Path(projectDir, service)}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// Write logs every complete line in p. A trailing partial line is held back until
+// the rest of it arrives or Flush is called.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+
+		if err := w.writeLine(string(w.buf[:idx])); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[idx+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush logs a trailing partial line, if any
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.writeLine(string(w.buf))
+	w.buf = nil
+	return err
+}
+
+// Close flushes and closes the log file
+func (w *Writer) Close() error {
+	w.Flush()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+func (w *Writer) writeLine(line string) error {
+	line = fmt.Sprintf("%s %s\n", time.Now().Format(timestampFormat), strings.TrimSuffix(line, "\r"))
+
+	if w.size > 0 && w.size+int64(len(line)) > MaxSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.file.WriteString(line)
+	w.size += int64(n)
+	return err
+}
+
+// rotate shifts <service>.log to <service>.log.1, .1 to .2 and so on, dropping the
+// oldest, then starts a new empty log file
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	os.Remove(backupPath(w.path, MaxBackups))
+	for n := MaxBackups - 1; n >= 1; n-- {
+		os.Rename(backupPath(w.path, n), backupPath(w.path, n+1))
+	}
+	if err := os.Rename(w.path, backupPath(w.path, 1)); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	return w.open()
+}
@@ -0,0 +1,155 @@
+package ports
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Conflict is a declared port that something else is already listening on
+type Conflict struct {
+	Service string
+	Port    int
+	Holder  string // the process holding the port, like "node (pid 1234)", if it could be found
+}
+
+// Check returns a conflict for every port in ports (service name to ports) that is already taken
+func Check(ports map[string][]int) []Conflict {
+	var conflicts []Conflict
+	for service, servicePorts := range ports {
+		for _, port := range servicePorts {
+			if !Free(port) {
+				conflicts = append(conflicts, Conflict{Service: service, Port: port, Holder: Holder(port)})
+			}
+		}
+	}
+	return conflicts
+}
+
+// loopbackHosts are dialed by Free, since on macOS and the BSDs binding every address
+// succeeds even while another process listens on one of these
+var loopbackHosts = []string{"127.0.0.1", "::1"}
+
+// Free reports whether nothing is listening on the TCP port
+func Free(port int) bool {
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+
+	for _, host := range loopbackHosts {
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), 250*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return false
+		}
+	}
+	return true
+}
+
+// Holder describes the process listening on a port using lsof, or returns an empty
+// string if lsof isn't installed or can't see the process
+func Holder(port int) string {
+	output, err := exec.Command("lsof", "-nP", fmt.Sprintf("-iTCP:%d", port), "-sTCP:LISTEN", "-Fpc").Output()
+	if err != nil {
+		return ""
+	}
+
+	// -F prints one field per line, prefixed with its name: p<pid> then c<command>
+	var pid, command string
+	for _, line := range strings.Split(string(output), "\n") {
+		switch {
+		case strings.HasPrefix(line, "p") && pid == "":
+			pid = line[1:]
+		case strings.HasPrefix(line, "c") && command == "":
+			command = line[1:]
+		}
+	}
+
+	if pid == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s (pid %s)", command, pid)
+}
+
+// Allocate finds a free TCP port by letting the OS pick one
+func Allocate() (int, error) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// assignmentsPath is where the ports picked for port = "auto" services are kept,
+// so every pane of a session and every restart uses the same one
+func assignmentsPath(projectDir string) string {
+	return filepath.Join(projectDir, ".runtime", "ports.json")
+}
+
+// Load returns the ports assigned to port = "auto" services in this session
+func Load(projectDir string) (map[string]int, error) {
+	assigned := map[string]int{}
+
+	data, err := os.ReadFile(assignmentsPath(projectDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return assigned, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &assigned); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", assignmentsPath(projectDir), err)
+	}
+	return assigned, nil
+}
+
+// Save records the ports assigned to port = "auto" services
+func Save(projectDir string, assigned map[string]int) error {
+	path := assignmentsPath(projectDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	data, err := json.MarshalIndent(assigned, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so readers never see a partial file
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to save assigned ports: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// Assigned returns the port assigned to a port = "auto" service, picking and
+// recording one if the session hasn't yet
+func Assigned(projectDir, service string) (int, error) {
+	assigned, err := Load(projectDir)
+	if err != nil {
+		return 0, err
+	}
+	if port, ok := assigned[service]; ok {
+		return port, nil
+	}
+
+	port, err := Allocate()
+	if err != nil {
+		return 0, err
+	}
+	assigned[service] = port
+	return port, Save(projectDir, assigned)
+}
@@ -0,0 +1,28 @@
+package ports
+
+import (
+	"net"
+	"testing"
+)
+
+// TestFreeSeesLoopbackListeners checks a port held on a loopback address only is
+// reported as taken, which binding every address misses on macOS and the BSDs
+func TestFreeSeesLoopbackListeners(t *testing.T) {
+	for _, host := range loopbackHosts {
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+		if err != nil {
+			t.Logf("skipping %s: %v", host, err)
+			continue
+		}
+
+		port := listener.Addr().(*net.TCPAddr).Port
+		if Free(port) {
+			t.Errorf("port %d is held on %s but reported free", port, host)
+		}
+
+		listener.Close()
+		if !Free(port) {
+			t.Errorf("port %d is reported taken after %s released it", port, host)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// ErrInstanceNotFound is returned by GetInstance when the service has no instance yet
+var ErrInstanceNotFound = errors.New("instance not found")
+
+// Instance is the machine a service is deployed to
+type Instance struct {
+	Name    string
+	Address string   // host or IP runtime deploy connects to over SSH
+	User    string   // SSH user; empty leaves it to ssh and ~/.ssh/config
+	Port    int      // SSH port; 0 for the default
+	Stopped bool     // exists but isn't running; StartInstance brings it back
+	Changes []string // how an existing instance differs from runtime.toml, like "machine type e2-micro → e2-medium"
+
+	// Disposable is set for machines the provider creates itself, which get new host
+	// keys every time, so SSH skips host key checking for them. Servers of your own
+	// keep it, since the secrets in the uploaded environment go to them too.
+	Disposable bool
+}
+
+// Provider creates and manages the machines services are deployed to. runtime deploy
+// talks to every deploy target through it, so adding a target only means adding a
+// Provider and registering it for its runsOn prefix.
+type Provider interface {
+	// Name identifies the provider in messages, like "GCP"
+	Name() string
+
+	// CheckRunsOn reports whether the provider can deploy to a runsOn value with its prefix
+	CheckRunsOn(runsOn string) error
+
+	// Validate checks credentials and access before anything is created
+	Validate(ctx context.Context, config utils.Config) error
+
+	// EnsureNetwork lets SSH and the given ports through to the project's instances
+	EnsureNetwork(ctx context.Context, config utils.Config, ports []int) error
+
+	// GetInstance returns the service's instance, or ErrInstanceNotFound. It reports
+	// whether the instance is stopped and how it differs from what runtime.toml asks for.
+	GetInstance(ctx context.Context, config utils.Config, service utils.Service) (Instance, error)
+
+	// StartInstance starts the service's stopped instance
+	StartInstance(ctx context.Context, config utils.Config, service utils.Service) (Instance, error)
+
+	// CreateInstance creates the service's instance, letting sshKey (a public key) log in
+	CreateInstance(ctx context.Context, config utils.Config, service utils.Service, sshKey string) (Instance, error)
+
+	// DeleteInstance removes the service's instance
+	DeleteInstance(ctx context.Context, config utils.Config, service utils.Service) error
+}
+
+// providers maps runsOn prefixes, like "gcp.", to the provider handling them
+var providers = map[string]Provider{}
+
+// Register makes a provider handle every runsOn value starting with prefix
+func Register(prefix string, provider Provider) {
+	providers[prefix] = provider
+}
+
+// ForRunsOn returns the provider for a service's runsOn value
+func ForRunsOn(runsOn string) (Provider, error) {
+	if runsOn == "" {
+		return nil, errors.New("runsOn is not set")
+	}
+
+	for prefix, provider := range providers {
+		if strings.HasPrefix(runsOn, prefix) {
+			if err := provider.CheckRunsOn(runsOn); err != nil {
+				return nil, err
+			}
+			return provider, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no deploy target handles '%s' (supported prefixes: %s)", runsOn, strings.Join(Prefixes(), ", "))
+}
+
+// CheckRunsOn reports whether some provider can deploy to runsOn
+func CheckRunsOn(runsOn string) error {
+	_, err := ForRunsOn(runsOn)
+	return err
+}
+
+// Prefixes returns the registered runsOn prefixes, sorted
+func Prefixes() []string {
+	prefixes := make([]string, 0, len(providers))
+	for prefix := range providers {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
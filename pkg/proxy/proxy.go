@@ -0,0 +1,182 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// invalidLabelChars are characters that can't appear in a hostname label
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// label turns a project or service name into something usable in a hostname
+func label(name string) string {
+	return strings.Trim(invalidLabelChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// Hostname returns the host the project is served on, like "shop.localhost"
+func Hostname(config utils.Config) string {
+	name := label(config.Name)
+	if name == "" {
+		name = "runtime"
+	}
+	return name + ".localhost"
+}
+
+// ServiceHostname returns the host a single service is served on, like "frontend.shop.localhost"
+func ServiceHostname(config utils.Config, service utils.Service) string {
+	return label(service.Name) + "." + Hostname(config)
+}
+
+// Server routes requests to services by hostname and by path prefix
+type Server struct {
+	Config   utils.Config
+	Assigned map[string]int // ports picked for port = "auto" services, by service name
+	Output   io.Writer      // where startup and error messages go
+
+	hosts   map[string]string // service hostname to service name
+	proxies map[string]*httputil.ReverseProxy
+}
+
+// Run listens on the [proxy] port until ctx is cancelled
+func (s *Server) Run(ctx context.Context) error {
+	if s.Config.Proxy == nil {
+		return errors.New("runtime.toml has no [proxy] section")
+	}
+
+	s.addServices()
+
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(s.Config.Proxy.Port))
+	if err != nil {
+		return fmt.Errorf("failed to start proxy on port %d: %w", s.Config.Proxy.Port, err)
+	}
+
+	server := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	s.printRoutes()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("proxy failed: %w", err)
+	}
+	return nil
+}
+
+// addServices sets up a hostname and a reverse proxy for every service with a port
+func (s *Server) addServices() {
+	s.hosts = map[string]string{}
+	s.proxies = map[string]*httputil.ReverseProxy{}
+	for _, service := range s.Config.Services {
+		port := s.port(service)
+		if port == 0 {
+			continue
+		}
+
+		s.hosts[ServiceHostname(s.Config, service)] = service.Name
+		s.proxies[service.Name] = s.reverseProxy(service.Name, port)
+	}
+}
+
+// port returns the port a service listens on, or 0 if it has none
+func (s *Server) port(service utils.Service) int {
+	if service.AutoPort {
+		return s.Assigned[service.Name]
+	}
+	return service.Port
+}
+
+// reverseProxy forwards to a service on localhost. httputil.ReverseProxy passes
+// WebSocket upgrades through, so dev servers' live reload keeps working.
+func (s *Server) reverseProxy(name string, port int) *httputil.ReverseProxy {
+	target := &url.URL{Scheme: "http", Host: "localhost:" + strconv.Itoa(port)}
+
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.SetXForwarded()
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			fmt.Fprintf(s.Output, "❌ %s %s → %s: %v\n", r.Method, r.URL.Path, name, err)
+			http.Error(w, fmt.Sprintf("runtime proxy: '%s' isn't answering on port %d. Is it running? Check with: runtime status", name, port),
+				http.StatusBadGateway)
+		},
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := s.route(r)
+	if !ok {
+		http.Error(w, fmt.Sprintf("runtime proxy: nothing is routed to %s%s. Available routes:\n%s", r.Host, r.URL.Path, s.describeRoutes()),
+			http.StatusNotFound)
+		return
+	}
+
+	proxy, ok := s.proxies[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("runtime proxy: '%s' isn't part of this session", name), http.StatusBadGateway)
+		return
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// route picks the service for a request: a service hostname wins, then the longest
+// matching path prefix, then the default service
+func (s *Server) route(r *http.Request) (string, bool) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if name, ok := s.hosts[strings.ToLower(host)]; ok {
+		return name, true
+	}
+
+	for _, route := range s.Config.Proxy.Routes {
+		if r.URL.Path == route.Prefix || strings.HasPrefix(r.URL.Path, route.Prefix+"/") {
+			return route.Service, true
+		}
+	}
+
+	if s.Config.Proxy.Default != "" {
+		return s.Config.Proxy.Default, true
+	}
+	return "", false
+}
+
+// printRoutes shows where each service can be reached
+func (s *Server) printRoutes() {
+	fmt.Fprintf(s.Output, "🔀 Proxy listening on http://%s:%d\n", Hostname(s.Config), s.Config.Proxy.Port)
+	fmt.Fprint(s.Output, s.describeRoutes())
+}
+
+func (s *Server) describeRoutes() string {
+	var b strings.Builder
+	proxyPort := s.Config.Proxy.Port
+
+	for _, route := range s.Config.Proxy.Routes {
+		fmt.Fprintf(&b, "   http://%s:%d%s/ → %s\n", Hostname(s.Config), proxyPort, route.Prefix, route.Service)
+	}
+	if s.Config.Proxy.Default != "" {
+		fmt.Fprintf(&b, "   http://%s:%d/ → %s\n", Hostname(s.Config), proxyPort, s.Config.Proxy.Default)
+	}
+
+	for _, service := range s.Config.Services {
+		if port := s.port(service); port != 0 {
+			fmt.Fprintf(&b, "   http://%s:%d → %s (port %d)\n", ServiceHostname(s.Config, service), proxyPort, service.Name, port)
+		}
+	}
+	return b.String()
+}
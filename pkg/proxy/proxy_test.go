@@ -0,0 +1,173 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// backend starts a service that answers with its name and the path it was asked for
+func backend(t *testing.T, name string) int {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %s", name, r.URL.Path, r.Header.Get("X-Forwarded-Host"))
+	}))
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	return port
+}
+
+// closedPort returns a port nothing listens on
+func closedPort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	return port
+}
+
+func TestServerRoutes(t *testing.T) {
+	config := utils.Config{
+		Name: "Shop",
+		Services: []utils.Service{
+			{Name: "api", Port: backend(t, "api")},
+			{Name: "web", AutoPort: true},
+			{Name: "docs"}, // no port, so nothing to proxy to
+			{Name: "down", Port: closedPort(t)},
+		},
+		Proxy: &utils.Proxy{
+			Port: 4000,
+			Routes: []utils.ProxyRoute{
+				{Prefix: "/api/v2", Service: "web"},
+				{Prefix: "/api", Service: "api"},
+				{Prefix: "/docs", Service: "docs"},
+			},
+		},
+	}
+
+	var output bytes.Buffer
+	server := &Server{Config: config, Assigned: map[string]int{"web": backend(t, "web")}, Output: &output}
+	server.addServices()
+	proxy := httptest.NewServer(server)
+	defer proxy.Close()
+
+	tests := []struct {
+		name   string
+		host   string
+		path   string
+		status int
+		body   string
+	}{
+		{name: "service hostname", host: "api.shop.localhost:4000", path: "/health", status: 200, body: "api /health api.shop.localhost:4000"},
+		{name: "hostname ignores case", host: "WEB.Shop.localhost", path: "/", status: 200, body: "web / WEB.Shop.localhost"},
+		{name: "hostname wins over routes", host: "web.shop.localhost", path: "/api/users", status: 200, body: "web /api/users"},
+		{name: "path prefix", host: "shop.localhost:4000", path: "/api/users", status: 200, body: "api /api/users"},
+		{name: "exact prefix", host: "shop.localhost:4000", path: "/api", status: 200, body: "api /api"},
+		{name: "longest prefix", host: "shop.localhost:4000", path: "/api/v2/users", status: 200, body: "web /api/v2/users"},
+		{name: "prefix only matches whole segments", host: "shop.localhost:4000", path: "/apis", status: 404, body: "nothing is routed to shop.localhost:4000/apis"},
+		{name: "unknown host", host: "nope.shop.localhost", path: "/", status: 404, body: "nothing is routed to nope.shop.localhost/. Available routes:"},
+		{name: "service without a port has no hostname", host: "docs.shop.localhost", path: "/", status: 404, body: "nothing is routed"},
+		{name: "route to service without a port", host: "shop.localhost", path: "/docs", status: 502, body: "'docs' isn't part of this session"},
+		{name: "service not answering", host: "down.shop.localhost", path: "/", status: 502, body: "'down' isn't answering on port"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, proxy.URL+tc.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Host = tc.host
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tc.status || !strings.Contains(string(body), tc.body) {
+				t.Errorf("got %d %q, want %d containing %q", resp.StatusCode, body, tc.status, tc.body)
+			}
+		})
+	}
+
+	// The unknown host's response lists where things can be reached
+	req, _ := http.NewRequest(http.MethodGet, proxy.URL, nil)
+	req.Host = "nope.localhost"
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, route := range []string{"http://shop.localhost:4000/api/ → api", "http://web.shop.localhost:4000 → web"} {
+		if !strings.Contains(string(body), route) {
+			t.Errorf("404 page doesn't list %q:\n%s", route, body)
+		}
+	}
+
+	if !strings.Contains(output.String(), "GET / → down") {
+		t.Errorf("the failed request to down wasn't reported, output:\n%s", output.String())
+	}
+}
+
+// TestServerDefault checks requests no route matches go to the default service
+func TestServerDefault(t *testing.T) {
+	config := utils.Config{
+		Name:     "shop",
+		Services: []utils.Service{{Name: "web", Port: backend(t, "web")}},
+		Proxy:    &utils.Proxy{Port: 4000, Default: "web"},
+	}
+
+	server := &Server{Config: config, Output: io.Discard}
+	server.addServices()
+	proxy := httptest.NewServer(server)
+	defer proxy.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, proxy.URL+"/anything", nil)
+	req.Host = "unknown.localhost"
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != 200 || !strings.HasPrefix(string(body), "web /anything") {
+		t.Errorf("got %d %q, want the default service", resp.StatusCode, body)
+	}
+}
+
+func TestHostname(t *testing.T) {
+	tests := []struct {
+		project string
+		service string
+		want    string
+	}{
+		{"shop", "api", "api.shop.localhost"},
+		{"My Shop", "Web_App", "web-app.my-shop.localhost"},
+		{"!!!", "api", "api.runtime.localhost"},
+	}
+
+	for _, tc := range tests {
+		config := utils.Config{Name: tc.project}
+		if got := ServiceHostname(config, utils.Service{Name: tc.service}); got != tc.want {
+			t.Errorf("ServiceHostname(%q, %q) = %q, want %q", tc.project, tc.service, got, tc.want)
+		}
+	}
+}
@@ -1,17 +1,54 @@
 package ssh
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type Client struct {
 	Host string // External IP address
-	User string // SSH username (default: "runtime")
+	User string // SSH username (default: "runtime"); empty leaves it to ssh and ~/.ssh/config
+	Port int    // SSH port; 0 for the default
+
+	// SkipHostKeyCheck turns host key checking off, for instances runtime has just
+	// created with new host keys. Otherwise a host seen for the first time is added
+	// to ~/.ssh/known_hosts and one whose key changed is refused.
+	SkipHostKeyCheck bool
+}
+
+// options are passed to every ssh and scp invocation
+func (c *Client) options(portFlag string) []string {
+	options := []string{"-o", "LogLevel=ERROR"}
+	if c.SkipHostKeyCheck {
+		options = append(options, "-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null")
+	} else {
+		options = append(options, "-o", "StrictHostKeyChecking=accept-new")
+	}
+	if c.Port != 0 {
+		options = append(options, portFlag, strconv.Itoa(c.Port))
+	}
+	return options
+}
+
+// destination is the user@host argument for ssh and scp
+func (c *Client) destination() string {
+	if c.User == "" {
+		return c.Host
+	}
+	return fmt.Sprintf("%s@%s", c.User, c.Host)
+}
+
+// command builds an ssh command that runs command on the remote host
+func (c *Client) command(command string, extraOptions ...string) *exec.Cmd {
+	args := append(c.options("-p"), extraOptions...)
+	args = append(args, c.destination(), command)
+	return exec.Command("ssh", args...)
 }
 
 // WaitForSSH waits until SSH is ready on the instance
@@ -20,20 +57,15 @@ func (c *Client) WaitForSSH(maxWait time.Duration) error {
 
 	deadline := time.Now().Add(maxWait)
 	attempt := 0
+	var lastOutput []byte
 
 	for time.Now().Before(deadline) {
 		attempt++
 
-		cmd := exec.Command("ssh",
-			"-o", "StrictHostKeyChecking=no",
-			"-o", "UserKnownHostsFile=/dev/null",
-			"-o", "ConnectTimeout=5",
-			"-o", "LogLevel=ERROR",
-			fmt.Sprintf("%s@%s", c.User, c.Host),
-			"echo 'ready'",
-		)
+		cmd := c.command("echo 'ready'", "-o", "ConnectTimeout=5")
 
-		if err := cmd.Run(); err == nil {
+		output, err := cmd.CombinedOutput()
+		if err == nil {
 			fmt.Printf("\r   ✅ SSH is ready                    \n")
 			return nil
 		}
@@ -42,13 +74,19 @@ func (c *Client) WaitForSSH(maxWait time.Duration) error {
 		spinners := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 		fmt.Printf("\r   %s Waiting for SSH to be ready... (attempt %d)", spinners[attempt%len(spinners)], attempt)
 
+		lastOutput = output
 		time.Sleep(3 * time.Second)
 	}
 
+	// Shows a refused host key, among other reasons
+	if message := strings.TrimSpace(string(lastOutput)); message != "" {
+		return fmt.Errorf("\nSSH did not become ready within %v: %s", maxWait, message)
+	}
 	return fmt.Errorf("\nSSH did not become ready within %v", maxWait)
 }
 
-// UploadDirectory uploads git-tracked files to the remote instance
+// UploadDirectory uploads git-tracked files to the remote instance. The files replace
+// whatever remotePath held, so files deleted since the last upload don't linger.
 func (c *Client) UploadDirectory(localPath, remotePath string) error {
 	// Find git root
 	gitRoot, err := findGitRoot(localPath)
@@ -126,20 +164,16 @@ func (c *Client) UploadDirectory(localPath, remotePath string) error {
 		return fmt.Errorf("\nfailed to create archive: %w", err)
 	}
 
-	// Create remote directory
-	if err := c.RunCommandQuiet(fmt.Sprintf("mkdir -p %s", remotePath)); err != nil {
+	// Extract into a fresh directory next to remotePath, swapped in once complete
+	stagingPath := remotePath + ".new"
+	if err := c.RunCommandQuiet(fmt.Sprintf("rm -rf %s && mkdir -p %s", stagingPath, stagingPath)); err != nil {
 		close(done)
 		return fmt.Errorf("\nfailed to create remote directory: %w", err)
 	}
 
 	// Upload tar file
-	scpCmd := exec.Command("scp",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-o", "LogLevel=ERROR",
-		tarFile,
-		fmt.Sprintf("%s@%s:%s/archive.tar", c.User, c.Host, remotePath),
-	)
+	scpArgs := append(c.options("-P"), tarFile, fmt.Sprintf("%s:%s/archive.tar", c.destination(), stagingPath))
+	scpCmd := exec.Command("scp", scpArgs...)
 
 	if err := scpCmd.Run(); err != nil {
 		close(done)
@@ -153,8 +187,8 @@ func (c *Client) UploadDirectory(localPath, remotePath string) error {
 		stripComponents = len(strings.Split(relPath, "/"))
 	}
 
-	extractCmd := fmt.Sprintf("cd %s && tar -xf archive.tar --strip-components=%d && rm archive.tar",
-		remotePath, stripComponents)
+	extractCmd := fmt.Sprintf("cd %s && tar -xf archive.tar --strip-components=%d && rm archive.tar && rm -rf %s && mv %s %s",
+		stagingPath, stripComponents, remotePath, stagingPath, remotePath)
 
 	if err := c.RunCommandQuiet(extractCmd); err != nil {
 		close(done)
@@ -193,13 +227,7 @@ func findGitRoot(startPath string) (string, error) {
 
 // RunCommand executes a command on the remote instance with output
 func (c *Client) RunCommand(command string) error {
-	cmd := exec.Command("ssh",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-o", "LogLevel=ERROR",
-		fmt.Sprintf("%s@%s", c.User, c.Host),
-		command,
-	)
+	cmd := c.command(command)
 
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -209,13 +237,31 @@ func (c *Client) RunCommand(command string) error {
 
 // RunCommandQuiet executes a command without showing output
 func (c *Client) RunCommandQuiet(command string) error {
-	cmd := exec.Command("ssh",
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-o", "LogLevel=ERROR",
-		fmt.Sprintf("%s@%s", c.User, c.Host),
-		command,
-	)
+	cmd := c.command(command)
 
 	return cmd.Run()
 }
+
+// Output runs a command on the remote host and returns what it printed
+func (c *Client) Output(command string) (string, error) {
+	output, err := c.command(command).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run '%s': %w", command, err)
+	}
+	return string(output), nil
+}
+
+// WriteFile writes content to a file on the remote instance, replacing it if it exists
+func (c *Client) WriteFile(remotePath string, content []byte, mode os.FileMode) error {
+	// The umask makes a new file private from the start, rather than readable until
+	// chmod runs; chmod still fixes the mode of a file that was already there
+	cmd := c.command(fmt.Sprintf("umask %03o && cat > %s && chmod %o %s", 0777&^mode.Perm(), remotePath, mode.Perm(), remotePath))
+
+	cmd.Stdin = bytes.NewReader(content)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write %s: %w\nOutput: %s", remotePath, err, output)
+	}
+
+	return nil
+}
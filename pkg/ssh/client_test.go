@@ -0,0 +1,20 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestHostKeyChecking checks only clients for disposable instances skip host key
+// checking; servers of your own are checked against ~/.ssh/known_hosts
+func TestHostKeyChecking(t *testing.T) {
+	own := strings.Join((&Client{Host: "10.0.0.5"}).options("-p"), " ")
+	if !strings.Contains(own, "StrictHostKeyChecking=accept-new") || strings.Contains(own, "UserKnownHostsFile") {
+		t.Errorf("own server options %q should check host keys against known_hosts", own)
+	}
+
+	disposable := strings.Join((&Client{Host: "10.0.0.5", SkipHostKeyCheck: true}).options("-p"), " ")
+	if !strings.Contains(disposable, "StrictHostKeyChecking=no") || !strings.Contains(disposable, "UserKnownHostsFile=/dev/null") {
+		t.Errorf("disposable instance options %q should skip host key checking", disposable)
+	}
+}
@@ -56,6 +56,6 @@ func GetOrCreateSSHKey() (string, error) {
 		return "", err
 	}
 
-	fmt.Println("✅ SSH key generated\n")
+	fmt.Print("✅ SSH key generated\n\n")
 	return strings.TrimSpace(string(data)), nil
 }
@@ -0,0 +1,120 @@
+package sshConnector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/The-Pirateship/runtime/pkg/provider"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// URLPrefix is the runsOn prefix for a server given inline, as in "ssh://deploy@10.0.0.5"
+const URLPrefix = "ssh://"
+
+// Provider deploys services to servers you already run. There is nothing to create:
+// every instance is the server runsOn points at, reached with your own SSH setup.
+type Provider struct{}
+
+// NewProvider returns an SSH provider
+func NewProvider() *Provider {
+	return &Provider{}
+}
+
+func (p *Provider) Name() string {
+	return "SSH"
+}
+
+func (p *Provider) CheckRunsOn(runsOn string) error {
+	if strings.HasPrefix(runsOn, utils.HostRunsOnPrefix) {
+		if strings.TrimPrefix(runsOn, utils.HostRunsOnPrefix) == "" {
+			return fmt.Errorf("'%s' is missing a host name, like 'ssh.web1' for [hosts.web1]", runsOn)
+		}
+		return nil
+	}
+
+	_, err := parseURL(runsOn)
+	return err
+}
+
+func (p *Provider) Validate(ctx context.Context, config utils.Config) error {
+	if _, err := exec.LookPath("ssh"); err != nil {
+		return errors.New("ssh is not installed; it is needed to deploy to your own servers")
+	}
+	return nil
+}
+
+func (p *Provider) EnsureNetwork(ctx context.Context, config utils.Config, ports []int) error {
+	if len(ports) == 0 {
+		return nil
+	}
+
+	described := make([]string, len(ports))
+	for i, port := range ports {
+		described[i] = strconv.Itoa(port)
+	}
+	fmt.Printf("ℹ️  runtime doesn't manage the firewall of your own servers; make sure port(s) %s are open\n\n", strings.Join(described, ", "))
+	return nil
+}
+
+func (p *Provider) GetInstance(ctx context.Context, config utils.Config, service utils.Service) (provider.Instance, error) {
+	if strings.HasPrefix(service.RunsOn, utils.HostRunsOnPrefix) {
+		name := strings.TrimPrefix(service.RunsOn, utils.HostRunsOnPrefix)
+		host, ok := config.Hosts[name]
+		if !ok {
+			return provider.Instance{}, fmt.Errorf("runtime.toml has no [hosts.%s] table", name)
+		}
+		return provider.Instance{Name: name, Address: host.Address, User: host.User, Port: host.Port}, nil
+	}
+
+	return parseURL(service.RunsOn)
+}
+
+// StartInstance is never needed: GetInstance doesn't know whether a server is up, and
+// deploy waits for SSH either way
+func (p *Provider) StartInstance(ctx context.Context, config utils.Config, service utils.Service) (provider.Instance, error) {
+	return p.GetInstance(ctx, config, service)
+}
+
+// CreateInstance returns the server as it is. The deploy key isn't installed, so
+// logging in relies on keys you have already set up for it.
+func (p *Provider) CreateInstance(ctx context.Context, config utils.Config, service utils.Service, sshKey string) (provider.Instance, error) {
+	instance, err := p.GetInstance(ctx, config, service)
+	if err != nil {
+		return provider.Instance{}, err
+	}
+
+	fmt.Printf("   🖥️  Using your server %s\n", instance.Name)
+	return instance, nil
+}
+
+func (p *Provider) DeleteInstance(ctx context.Context, config utils.Config, service utils.Service) error {
+	return fmt.Errorf("'%s' is your own server, so runtime won't delete it", service.RunsOn)
+}
+
+// parseURL reads a runsOn value like "ssh://deploy@10.0.0.5:2222"
+func parseURL(runsOn string) (provider.Instance, error) {
+	u, err := url.Parse(runsOn)
+	if err != nil || u.Scheme != "ssh" || u.Hostname() == "" || (u.Path != "" && u.Path != "/") {
+		return provider.Instance{}, fmt.Errorf("'%s' is not a valid ssh address, expected ssh://[user@]host[:port]", runsOn)
+	}
+
+	instance := provider.Instance{
+		Name:    strings.TrimPrefix(runsOn, URLPrefix),
+		Address: u.Hostname(),
+		User:    u.User.Username(),
+	}
+	if u.Port() != "" {
+		port, err := strconv.Atoi(u.Port())
+		if err != nil || port < 1 || port > 65535 {
+			return provider.Instance{}, fmt.Errorf("'%s' has an invalid port '%s'", runsOn, u.Port())
+		}
+		instance.Port = port
+	}
+
+	return instance, nil
+}
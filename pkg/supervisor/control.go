@@ -0,0 +1,56 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Command is a request sent to a running supervisor through its control file
+type Command string
+
+const (
+	CommandRestart Command = "restart" // restart the service, or start it again if it has stopped
+	CommandStop    Command = "stop"    // stop the service but keep its pane, so it can be restarted
+)
+
+// controlPath is the file a supervisor polls for commands, next to its state file
+func controlPath(dir, service string) string {
+	return filepath.Join(dir, service+".control")
+}
+
+// SendCommand asks the supervisor of a service to act. The supervisor picks the
+// command up within a fraction of a second and reports the outcome in its state.
+func SendCommand(dir, service string, command Command) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	path := controlPath(dir, service)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(command), 0644); err != nil {
+		return fmt.Errorf("failed to send %s to '%s': %w", command, service, err)
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// takeCommand returns the pending command for a service, if any, and removes it
+func takeCommand(dir, service string) (Command, error) {
+	path := controlPath(dir, service)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Remove(path); err != nil {
+		return "", err
+	}
+	return Command(strings.TrimSpace(string(data))), nil
+}
@@ -0,0 +1,130 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// prefixColors are the ANSI colors cycled through for service name prefixes
+var prefixColors = []string{"36", "33", "32", "35", "34", "91", "96", "93", "92", "95"}
+
+// Group runs every service in the config as child processes of this one,
+// interleaving their output with a coloured name prefix on each line
+type Group struct {
+	Config      utils.Config
+	Output      io.Writer
+	Color       bool
+	GracePeriod time.Duration
+
+	mu      sync.Mutex
+	runners []*Runner
+}
+
+// Run starts all services and blocks until ctx is cancelled, which asks every
+// service to stop and waits up to the grace period before killing it.
+func (g *Group) Run(ctx context.Context) {
+	width := 0
+	for _, service := range g.Config.Services {
+		if len(service.Name) > width {
+			width = len(service.Name)
+		}
+	}
+
+	var outputMu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i, service := range g.Config.Services {
+		prefix := fmt.Sprintf("%-*s | ", width, service.Name)
+		if g.Color {
+			prefix = fmt.Sprintf("\033[%sm%s\033[0m", prefixColors[i%len(prefixColors)], prefix)
+		}
+		out := &prefixWriter{prefix: prefix, out: g.Output, mu: &outputMu}
+
+		runner := &Runner{
+			Config:          g.Config,
+			Service:         service,
+			Stdout:          out,
+			Stderr:          out,
+			GracePeriod:     g.GracePeriod,
+			OwnProcessGroup: true,
+		}
+
+		g.mu.Lock()
+		g.runners = append(g.runners, runner)
+		g.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer out.Flush()
+
+			// The runner reports exits itself, so only errors are left to show
+			if _, err := runner.Run(ctx); err != nil {
+				// Being stopped while waiting for dependencies isn't worth reporting
+				if ctx.Err() == nil {
+					fmt.Fprintf(out, "❌ %v\n", err)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// Kill immediately kills every running service, used when the user won't wait for the grace period
+func (g *Group) Kill() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, runner := range g.runners {
+		runner.Kill()
+	}
+}
+
+// prefixWriter writes complete lines to out, each starting with prefix. Writers
+// that share mu never interleave within a line.
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+
+		line := strings.TrimSuffix(string(w.buf[:idx]), "\r")
+		if _, err := fmt.Fprintf(w.out, "%s%s\n", w.prefix, line); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[idx+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes out a trailing partial line, if any
+func (w *prefixWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf)
+		w.buf = nil
+	}
+}
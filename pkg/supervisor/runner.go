@@ -0,0 +1,558 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/health"
+	"github.com/The-Pirateship/runtime/pkg/logs"
+	"github.com/The-Pirateship/runtime/pkg/ports"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"github.com/The-Pirateship/runtime/pkg/watch"
+)
+
+// DefaultGracePeriod is how long a service gets to exit after being asked to stop
+const DefaultGracePeriod = 5 * time.Second
+
+// Runner starts a single service once its dependencies are up and records its state
+type Runner struct {
+	Config      utils.Config
+	Service     utils.Service
+	Stdin       io.Reader
+	Stdout      io.Writer
+	Stderr      io.Writer
+	GracePeriod time.Duration
+
+	// Multiplexer and Session identify the session the service's pane belongs to,
+	// recorded in its state so runtime stop can end the session
+	Multiplexer string
+	Session     string
+
+	// OwnProcessGroup runs the service in its own process group. Used when several
+	// services share a terminal, so Ctrl-C goes to the supervisor rather than every child.
+	OwnProcessGroup bool
+
+	// Terminal runs the service on a pseudo-terminal fed from Stdin, so it behaves as
+	// if it owned the pane while its output is still copied to the log. Used by panes.
+	Terminal bool
+
+	stateMu      sync.Mutex
+	state        State
+	cmd          *exec.Cmd
+	term         *terminal
+	groupSignals bool
+
+	log       *logs.Writer
+	inputOnce sync.Once
+	changes   chan []string // files that changed under a watch pattern
+}
+
+// restartResetAfter is how long a service has to stay up for its restart attempts to start over
+const restartResetAfter = 30 * time.Second
+
+// controlPollInterval is how often a supervisor checks for commands from runtime restart/stop
+const controlPollInterval = 250 * time.Millisecond
+
+// Run waits for dependencies and runs the service, restarting it as its restart
+// policy says. When the service exits for good or is stopped with runtime stop,
+// Run keeps waiting so runtime restart can start it again. It returns the last
+// exit code once ctx is cancelled.
+func (r *Runner) Run(ctx context.Context) (int, error) {
+	stateDir := StateDir(r.Config.Dir)
+	r.state = State{Service: r.Service.Name, SupervisorPID: os.Getpid(), Multiplexer: r.Multiplexer, Session: r.Session}
+
+	// Output is kept in .runtime/logs so it outlives the pane's scrollback
+	log, err := logs.Open(r.Config.Dir, r.Service.Name)
+	if err != nil {
+		return 1, err
+	}
+	defer log.Close()
+	r.log = log
+
+	if len(r.Service.Watch) > 0 {
+		r.changes = make(chan []string, 1)
+		go r.watchFiles(ctx)
+	}
+
+	if err := r.updateState(func(s *State) { s.Status = StatusWaiting }); err != nil {
+		return 1, err
+	}
+
+	// Drop commands meant for a previous session
+	if _, err := takeCommand(stateDir, r.Service.Name); err != nil {
+		return 1, err
+	}
+
+	if err := r.waitForDependencies(ctx, stateDir); err != nil {
+		return 1, err
+	}
+
+	attempts := 0
+	for {
+		startedAt := time.Now()
+		exitCode, command, err := r.runOnce(ctx, stateDir)
+		if err != nil {
+			return exitCode, err
+		}
+		if ctx.Err() != nil {
+			return exitCode, nil
+		}
+
+		// A service that stayed up for a while has recovered, so its retries start over
+		if time.Since(startedAt) >= restartResetAfter {
+			attempts = 0
+		}
+
+		stopped := command == CommandStop
+		switch command {
+		case CommandRestart:
+			r.printf("🔄 Restarting %s\n", r.Service.Name)
+			attempts = 0
+			continue
+		case CommandStop:
+			r.printf("⏹️  %s stopped, run `runtime restart %s` to start it again\n", r.Service.Name, r.Service.Name)
+		default:
+			policy := r.Service.Restart
+			if !policy.ShouldRestart(exitCode) {
+				r.printf("⛔ %s exited with code %d, run `runtime restart %s` to start it again\n", r.Service.Name, exitCode, r.Service.Name)
+				break
+			}
+			if policy.MaxRetries > 0 && attempts >= policy.MaxRetries {
+				r.printf("⛔ %s exited with code %d and was restarted %d times in a row, giving up. Run `runtime restart %s` to try again\n",
+					r.Service.Name, exitCode, attempts, r.Service.Name)
+				break
+			}
+
+			attempts++
+			delay := policy.Delay(attempts)
+			r.printf("🔁 %s exited with code %d, restarting in %v (attempt %d)\n", r.Service.Name, exitCode, delay, attempts)
+			if err := r.updateState(func(s *State) {
+				s.Status = StatusRestarting
+				s.Restarts++
+			}); err != nil {
+				return exitCode, err
+			}
+
+			command, err := r.waitForCommand(ctx, stateDir, delay, true)
+			if err != nil {
+				return exitCode, nil
+			}
+			if command == CommandRestart {
+				continue
+			}
+
+			// Stopped while waiting to restart
+			stopped = true
+			if err := r.updateState(func(s *State) { s.Status = StatusStopped }); err != nil {
+				return exitCode, err
+			}
+			r.printf("⏹️  %s stopped, run `runtime restart %s` to start it again\n", r.Service.Name, r.Service.Name)
+		}
+
+		// Stay around until runtime restart asks for the service again. A crashed
+		// service also comes back when its files change, hopefully with a fix.
+		for {
+			command, err := r.waitForCommand(ctx, stateDir, 0, !stopped)
+			if err != nil {
+				return exitCode, nil
+			}
+			if command == CommandRestart {
+				break
+			}
+		}
+		attempts = 0
+		r.printf("🚀 Starting %s\n", r.Service.Name)
+	}
+}
+
+// runOnce starts the service and waits until it exits, ctx is cancelled, or a
+// restart or stop command arrives. It returns the exit code and the command, if any.
+func (r *Runner) runOnce(ctx context.Context, stateDir string) (int, Command, error) {
+	// Resolve the environment on every start so edits to env files are picked up by restarts
+	env, err := r.Config.ResolveEnv(r.Service)
+	if err != nil {
+		return 1, "", err
+	}
+
+	// Services with port = "auto" get the port runtime dev picked, unless env sets PORT itself
+	if _, ok := env["PORT"]; r.Service.AutoPort && !ok {
+		port, err := ports.Assigned(r.Config.Dir, r.Service.Name)
+		if err != nil {
+			return 1, "", err
+		}
+		env["PORT"] = strconv.Itoa(port)
+	}
+
+	cmd := exec.Command("sh", "-c", r.Service.Command)
+	cmd.Dir = r.Service.Path
+	cmd.Env = append(os.Environ(), utils.EnvList(env)...)
+
+	t, err := r.start(cmd)
+	if err != nil {
+		return 1, "", fmt.Errorf("failed to start '%s': %w", r.Service.Name, err)
+	}
+
+	if err := r.updateState(func(s *State) {
+		r.cmd = cmd
+		r.term = t
+		r.groupSignals = r.OwnProcessGroup || t != nil
+		s.Status = StatusRunning
+		s.PID = cmd.Process.Pid
+		s.StartedAt = time.Now()
+	}); err != nil {
+		return 1, "", err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	// Health checking stops as soon as the process exits
+	healthCtx, cancelHealth := context.WithCancel(ctx)
+	defer cancelHealth()
+	if r.Service.HealthCheck != nil {
+		go r.watchHealth(healthCtx, cmd.Env)
+	}
+
+	command, waitErr := r.waitForExit(ctx, stateDir, cmd, done)
+	cancelHealth()
+
+	r.stateMu.Lock()
+	r.term = nil
+	r.stateMu.Unlock()
+	if t != nil {
+		t.close()
+	}
+	r.log.Flush()
+
+	exitCode := exitCodeOf(waitErr)
+
+	status := StatusExited
+	if command == CommandStop {
+		status = StatusStopped
+	}
+	if err := r.updateState(func(s *State) {
+		r.cmd = nil
+		s.Status = status
+		s.PID = 0
+		s.ExitCode = exitCode
+	}); err != nil {
+		return exitCode, command, err
+	}
+
+	return exitCode, command, nil
+}
+
+// start starts the service with its output going to Stdout and the log, on a
+// pseudo-terminal when Terminal is set and the platform supports it
+func (r *Runner) start(cmd *exec.Cmd) (*terminal, error) {
+	stdout := io.MultiWriter(r.Stdout, r.log)
+
+	if r.Terminal {
+		t, err := startOnTerminal(cmd, stdout)
+		if err == nil {
+			r.inputOnce.Do(func() { go r.forwardInput() })
+			return t, nil
+		}
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return nil, err
+		}
+	}
+
+	// Sharing one writer for both streams keeps their lines in order
+	stderr := stdout
+	if r.Stderr != r.Stdout {
+		stderr = io.MultiWriter(r.Stderr, r.log)
+	}
+
+	cmd.Stdin = r.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if r.OwnProcessGroup {
+		setProcessGroup(cmd)
+	}
+
+	return nil, cmd.Start()
+}
+
+// forwardInput copies Stdin to whichever pseudo-terminal the service is running on.
+// Input arriving while the service isn't running is dropped.
+func (r *Runner) forwardInput() {
+	buf := make([]byte, 1024)
+	for {
+		n, err := r.Stdin.Read(buf)
+		if n > 0 {
+			r.stateMu.Lock()
+			t := r.term
+			r.stateMu.Unlock()
+
+			if t != nil {
+				t.write(buf[:n])
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// printf shows a message from the supervisor in the service's output and its log
+func (r *Runner) printf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	r.log.Write([]byte(message))
+
+	// Our terminal is in raw mode while a service runs on a pseudo-terminal, so end lines explicitly
+	if r.Terminal {
+		message = strings.ReplaceAll(message, "\n", "\r\n")
+	}
+	fmt.Fprint(r.Stdout, message)
+}
+
+// waitForExit waits for the process to exit, stopping it when ctx is cancelled or a
+// restart or stop command arrives. It returns the command, if any, and cmd.Wait's result.
+func (r *Runner) waitForExit(ctx context.Context, stateDir string, cmd *exec.Cmd, done <-chan error) (Command, error) {
+	ticker := time.NewTicker(controlPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			return "", err
+		case <-ctx.Done():
+			return "", r.stop(cmd, done)
+		case changed := <-r.changes:
+			r.printf("👀 %s changed\n", describeChanges(changed))
+			return CommandRestart, r.stop(cmd, done)
+		case <-ticker.C:
+			command, err := takeCommand(stateDir, r.Service.Name)
+			if err == nil && (command == CommandRestart || command == CommandStop) {
+				return command, r.stop(cmd, done)
+			}
+		}
+	}
+}
+
+// waitForCommand blocks until a restart or stop command arrives and returns it.
+// With a timeout, reaching it counts as a restart, as do watched file changes
+// when restartOnChange is set.
+func (r *Runner) waitForCommand(ctx context.Context, stateDir string, timeout time.Duration, restartOnChange bool) (Command, error) {
+	ticker := time.NewTicker(controlPollInterval)
+	defer ticker.Stop()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		expired = time.After(timeout)
+	}
+
+	// A nil channel never delivers, which leaves changes to be picked up later
+	var changes <-chan []string
+	if restartOnChange {
+		changes = r.changes
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-expired:
+			return CommandRestart, nil
+		case changed := <-changes:
+			r.printf("👀 %s changed\n", describeChanges(changed))
+			return CommandRestart, nil
+		case <-ticker.C:
+			command, err := takeCommand(stateDir, r.Service.Name)
+			if err == nil && (command == CommandRestart || command == CommandStop) {
+				return command, nil
+			}
+		}
+	}
+}
+
+// watchFiles reports changes to the service's watched files on r.changes until ctx is cancelled
+func (r *Runner) watchFiles(ctx context.Context) {
+	watcher := &watch.Watcher{
+		Root:     r.Service.Path,
+		Patterns: r.Service.Watch,
+		Ignore:   r.Service.Ignore,
+		OnError: func(err error) {
+			r.printf("⚠️  File watcher error for %s: %v\n", r.Service.Name, err)
+		},
+	}
+
+	err := watcher.Run(ctx, func(changed []string) {
+		// If a restart is already pending, it covers these changes too
+		select {
+		case r.changes <- changed:
+		default:
+		}
+	})
+	if err != nil {
+		r.printf("⚠️  Not watching files for %s: %v\n", r.Service.Name, err)
+	}
+}
+
+// describeChanges summarises changed files for a message, like "main.go and 2 more"
+func describeChanges(changed []string) string {
+	if len(changed) == 1 {
+		return changed[0]
+	}
+	return fmt.Sprintf("%s and %d more", changed[0], len(changed)-1)
+}
+
+// Running reports whether the service's process is currently running
+func (r *Runner) Running() bool {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	return r.cmd != nil
+}
+
+// exitCodeOf converts the result of cmd.Wait into an exit code, using the shell's
+// 128+signal convention for processes killed by a signal
+func exitCodeOf(waitErr error) int {
+	var exitErr *exec.ExitError
+	if !errors.As(waitErr, &exitErr) {
+		if waitErr != nil {
+			return 1
+		}
+		return 0
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
+
+// updateState applies a change to the service's state and persists it
+func (r *Runner) updateState(change func(s *State)) error {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	change(&r.state)
+	return WriteState(StateDir(r.Config.Dir), r.state)
+}
+
+// watchHealth polls the health check until it passes and records the result
+func (r *Runner) watchHealth(ctx context.Context, env []string) {
+	check := *r.Service.HealthCheck
+
+	err := health.Wait(ctx, check, func(ctx context.Context) error {
+		return health.Check(ctx, check, r.Service.Path, env)
+	}, nil)
+	if ctx.Err() != nil {
+		return
+	}
+
+	status := StatusHealthy
+	if err != nil {
+		status = StatusUnhealthy
+		r.printf("⚠️  %s is unhealthy: %v\n", r.Service.Name, err)
+	} else {
+		r.printf("✅ %s is healthy (%s)\n", r.Service.Name, health.Describe(check))
+	}
+
+	r.updateState(func(s *State) {
+		// The process may have exited while the last check was running
+		if s.Status == StatusRunning {
+			s.Status = status
+		}
+	})
+}
+
+// stop asks the service to exit and kills it if it is still running after the grace period
+func (r *Runner) stop(cmd *exec.Cmd, done <-chan error) error {
+	grace := r.GracePeriod
+	if grace == 0 {
+		grace = DefaultGracePeriod
+	}
+
+	// Signal isn't supported everywhere (e.g. Windows), so fall back to killing outright
+	if err := r.signal(cmd, syscall.SIGTERM); err != nil {
+		cmd.Process.Kill()
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(grace):
+		r.printf("⚠️  %s did not exit within %v, killing it\n", r.Service.Name, grace)
+		r.kill(cmd)
+		return <-done
+	}
+}
+
+// Kill immediately kills the service if it is running
+func (r *Runner) Kill() {
+	r.stateMu.Lock()
+	cmd := r.cmd
+	r.stateMu.Unlock()
+
+	if cmd != nil {
+		r.kill(cmd)
+	}
+}
+
+func (r *Runner) kill(cmd *exec.Cmd) {
+	if err := r.signal(cmd, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
+
+// signal delivers sig to the service, including its children when it has its own process group
+func (r *Runner) signal(cmd *exec.Cmd, sig syscall.Signal) error {
+	r.stateMu.Lock()
+	groupSignals := r.groupSignals
+	r.stateMu.Unlock()
+
+	if groupSignals {
+		return signalProcessGroup(cmd, sig)
+	}
+	return cmd.Process.Signal(sig)
+}
+
+// waitForDependencies blocks until every service in dependsOn has started, or is
+// healthy if it defines a health check
+func (r *Runner) waitForDependencies(ctx context.Context, stateDir string) error {
+	if len(r.Service.DependsOn) == 0 {
+		return nil
+	}
+
+	r.printf("⏳ Waiting for %s...\n", strings.Join(r.Service.DependsOn, ", "))
+
+	for {
+		ready := true
+		for _, dep := range r.Service.DependsOn {
+			depState, err := ReadState(stateDir, dep)
+			if err != nil {
+				return err
+			}
+			depService, _ := r.Config.Service(dep)
+			if !depState.Ready(depService.HealthCheck != nil) {
+				ready = false
+				break
+			}
+		}
+
+		if ready {
+			r.printf("✅ Dependencies ready, starting %s\n", r.Service.Name)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusWaiting    Status = "waiting" // waiting for dependencies
+	StatusRunning    Status = "running"
+	StatusHealthy    Status = "healthy"   // running and passing its health check
+	StatusUnhealthy  Status = "unhealthy" // running but the health check gave up
+	StatusExited     Status = "exited"
+	StatusStopped    Status = "stopped"    // stopped with runtime stop, waiting to be restarted
+	StatusRestarting Status = "restarting" // exited and waiting out its restart backoff
+)
+
+// State is what a supervisor records about its service in .runtime/state/<service>.json,
+// so other panes and commands can see it without talking to the process directly
+type State struct {
+	Service   string    `json:"service"`
+	Status    Status    `json:"status"`
+	PID       int       `json:"pid,omitempty"`
+	ExitCode  int       `json:"exitCode"`
+	Restarts  int       `json:"restarts"` // automatic restarts by the restart policy
+	StartedAt time.Time `json:"startedAt,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Who is supervising the service, so commands like runtime stop can reach it
+	SupervisorPID int    `json:"supervisorPid,omitempty"`
+	Multiplexer   string `json:"multiplexer,omitempty"` // "zellij", "tmux", or empty in plain mode
+	Session       string `json:"session,omitempty"`     // multiplexer session name
+}
+
+// Ready reports whether dependents may start. Services with a health check must
+// pass it; others only need to be running.
+func (s State) Ready(hasHealthCheck bool) bool {
+	if hasHealthCheck {
+		return s.Status == StatusHealthy
+	}
+	return s.Status == StatusRunning || s.Status == StatusHealthy
+}
+
+// Supervised reports whether the supervisor that wrote this state is still alive.
+// State left behind by a session that has ended is not.
+func (s State) Supervised() bool {
+	return s.SupervisorPID != 0 && processAlive(s.SupervisorPID)
+}
+
+// StateDir returns where state files for a project live
+func StateDir(projectDir string) string {
+	return filepath.Join(projectDir, ".runtime", "state")
+}
+
+// WriteState atomically replaces the state file for a service
+func WriteState(dir string, state State) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	state.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so readers never see a partial file
+	path := filepath.Join(dir, state.Service+".json")
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// ReadState returns the recorded state for a service. A service that has not
+// written any state yet is reported with an empty Status and no error.
+func ReadState(dir, service string) (State, error) {
+	data, err := os.ReadFile(filepath.Join(dir, service+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return State{Service: service}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("failed to read state for '%s': %w", service, err)
+	}
+
+	return state, nil
+}
+
+// ClearStates removes all state files, used when a fresh session starts
+func ClearStates(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear state: %w", err)
+	}
+	return nil
+}
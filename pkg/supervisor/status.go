@@ -0,0 +1,83 @@
+package supervisor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/ports"
+	"github.com/The-Pirateship/runtime/pkg/utils"
+)
+
+// statusIcons gives each status a glyph so the table is readable at a glance
+var statusIcons = map[Status]string{
+	StatusWaiting:    "⏳",
+	StatusRunning:    "🟢",
+	StatusHealthy:    "✅",
+	StatusUnhealthy:  "🟠",
+	StatusExited:     "⛔",
+	StatusStopped:    "⏹️",
+	StatusRestarting: "🔁",
+}
+
+// PrintStatus writes a table with the current state of every service in the config
+func PrintStatus(w io.Writer, config utils.Config) error {
+	stateDir := StateDir(config.Dir)
+
+	assigned, err := ports.Load(config.Dir)
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "SERVICE\tSTATUS\tPID\tPORTS\tUPTIME\tRESTARTS\tLAST EXIT")
+
+	for _, service := range config.Services {
+		state, err := ReadState(stateDir, service.Name)
+		if err != nil {
+			return err
+		}
+
+		status := "not started"
+		switch {
+		case state.Status == "":
+		case !state.Supervised():
+			// Left behind by a session that has ended
+			status = "not running"
+			state.PID = 0
+		default:
+			status = fmt.Sprintf("%s %s", statusIcons[state.Status], state.Status)
+		}
+
+		pid, uptime, lastExit := "-", "-", "-"
+		if state.PID != 0 {
+			pid = fmt.Sprint(state.PID)
+			uptime = time.Since(state.StartedAt).Round(time.Second).String()
+		}
+		if state.Status == StatusExited || state.Status == StatusStopped || state.Status == StatusRestarting {
+			lastExit = fmt.Sprint(state.ExitCode)
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", service.Name, status, pid, describePorts(service, assigned), uptime, state.Restarts, lastExit)
+	}
+
+	return table.Flush()
+}
+
+// describePorts lists the ports a service listens on, including the one picked for port = "auto"
+func describePorts(service utils.Service, assigned map[string]int) string {
+	var list []string
+	if port, ok := assigned[service.Name]; ok && service.AutoPort {
+		list = append(list, fmt.Sprint(port))
+	}
+	for _, port := range service.Ports {
+		list = append(list, fmt.Sprint(port))
+	}
+
+	if len(list) == 0 {
+		return "-"
+	}
+	return strings.Join(list, ",")
+}
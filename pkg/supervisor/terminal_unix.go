@@ -0,0 +1,80 @@
+//go:build !windows
+
+package supervisor
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"golang.org/x/term"
+)
+
+// terminal is the pseudo-terminal a service runs on inside a pane. The service
+// still sees a TTY (colours, interactive prompts) while the supervisor copies its
+// output to the log.
+type terminal struct {
+	pty     *os.File
+	copied  chan struct{}
+	resized chan os.Signal
+	restore func()
+}
+
+// startOnTerminal starts cmd on a new pseudo-terminal the size of ours, copying
+// everything it prints to out
+func startOnTerminal(cmd *exec.Cmd, out io.Writer) (*terminal, error) {
+	ptmx, err := pty.Start(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &terminal{pty: ptmx, copied: make(chan struct{}), resized: make(chan os.Signal, 1)}
+	pty.InheritSize(os.Stdin, ptmx)
+
+	// Pass keystrokes through untouched, so Ctrl-C reaches the service via its own terminal
+	fd := int(os.Stdin.Fd())
+	if state, err := term.MakeRaw(fd); err == nil {
+		t.restore = func() { term.Restore(fd, state) }
+	}
+
+	// Follow the pane when it is resized
+	signal.Notify(t.resized, syscall.SIGWINCH)
+	go func() {
+		for range t.resized {
+			pty.InheritSize(os.Stdin, ptmx)
+		}
+	}()
+
+	go func() {
+		io.Copy(out, ptmx)
+		close(t.copied)
+	}()
+
+	return t, nil
+}
+
+// write forwards input to the service
+func (t *terminal) write(p []byte) {
+	t.pty.Write(p)
+}
+
+// close releases the pseudo-terminal once the service has exited and gives our
+// terminal back its normal settings
+func (t *terminal) close() {
+	// Output still buffered in the pseudo-terminal is usually drained right away
+	select {
+	case <-t.copied:
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	signal.Stop(t.resized)
+	close(t.resized)
+	t.pty.Close()
+	if t.restore != nil {
+		t.restore()
+	}
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package supervisor
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+)
+
+// terminal is unused on Windows, where services always write to pipes
+type terminal struct{}
+
+// startOnTerminal isn't supported on Windows, which has no pseudo-terminals
+func startOnTerminal(cmd *exec.Cmd, out io.Writer) (*terminal, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (t *terminal) write(p []byte) {}
+
+func (t *terminal) close() {}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"strings"
+)
+
+// Service returns the service with the given name
+func (c Config) Service(name string) (Service, bool) {
+	for _, service := range c.Services {
+		if service.Name == name {
+			return service, true
+		}
+	}
+	return Service{}, false
+}
+
+// StartOrder returns services sorted so every service comes after its dependencies.
+// Services without a dependency relationship keep the order they have in the file.
+// The config loader rejects cycles, so every service is always included.
+func StartOrder(services []Service) []Service {
+	byName := map[string]Service{}
+	for _, service := range services {
+		byName[service.Name] = service
+	}
+
+	ordered := make([]Service, 0, len(services))
+	visited := map[string]bool{}
+
+	var visit func(service Service)
+	visit = func(service Service) {
+		if visited[service.Name] {
+			return
+		}
+		visited[service.Name] = true
+
+		for _, dep := range service.DependsOn {
+			if depService, ok := byName[dep]; ok {
+				visit(depService)
+			}
+		}
+		ordered = append(ordered, service)
+	}
+
+	for _, service := range services {
+		visit(service)
+	}
+
+	return ordered
+}
+
+// checkDependencies reports dependsOn entries that name no known service and cycles
+// in the dependency graph
+func (p *configParser) checkDependencies(entries []serviceEntry, services []Service) {
+	byName := map[string]Service{}
+	entryByName := map[string]serviceEntry{}
+	for i, service := range services {
+		byName[service.Name] = service
+		entryByName[service.Name] = entries[i]
+	}
+
+	for _, service := range services {
+		entry := entryByName[service.Name]
+		for _, dep := range service.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				p.report(newKeyError(p.filename, getPosition(entry.tree, "dependsOn"), joinKey(entry.keyPath, "dependsOn"),
+					"references unknown service '%s'", dep))
+			}
+		}
+	}
+
+	// Depth-first search, tracking the current path so a cycle can be reported in full
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := map[string]int{}
+	var path []string
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = inProgress
+		path = append(path, name)
+
+		for _, dep := range byName[name].DependsOn {
+			switch state[dep] {
+			case inProgress:
+				// Trim the path to start at the service that closes the loop
+				start := 0
+				for i, n := range path {
+					if n == dep {
+						start = i
+						break
+					}
+				}
+				cycle := append(append([]string{}, path[start:]...), dep)
+				entry := entryByName[name]
+				p.report(newKeyError(p.filename, getPosition(entry.tree, "dependsOn"), joinKey(entry.keyPath, "dependsOn"),
+					"creates a dependency cycle: %s", strings.Join(cycle, " -> ")))
+			case unvisited:
+				visit(dep)
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = done
+	}
+
+	for _, service := range services {
+		if state[service.Name] == unvisited {
+			visit(service.Name)
+		}
+	}
+}
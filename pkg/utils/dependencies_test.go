@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []string // service = dependsOn
+		err      string
+	}{
+		{
+			name:     "diamond",
+			services: []string{`app = ["a", "b"]`, `a = ["base"]`, `b = ["base"]`, `base = []`},
+		},
+		{
+			name:     "self",
+			services: []string{`api = ["api"]`},
+			err:      "'services.api.dependsOn' creates a dependency cycle: api -> api",
+		},
+		{
+			name:     "two services",
+			services: []string{`a = ["b"]`, `b = ["a"]`},
+			err:      "'services.b.dependsOn' creates a dependency cycle: a -> b -> a",
+		},
+		{
+			name:     "three services",
+			services: []string{`a = ["b"]`, `b = ["c"]`, `c = ["a"]`},
+			err:      "'services.c.dependsOn' creates a dependency cycle: a -> b -> c -> a",
+		},
+		{
+			name:     "cycle reached through another service",
+			services: []string{`web = ["api"]`, `api = ["db"]`, `db = ["api"]`},
+			err:      "'services.db.dependsOn' creates a dependency cycle: api -> db -> api",
+		},
+		{
+			name:     "unknown service",
+			services: []string{`api = ["db", "nope"]`, `db = []`},
+			err:      "'services.api.dependsOn' references unknown service 'nope'",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			document := "[services]\n"
+			for _, service := range tc.services {
+				name, dependsOn, _ := strings.Cut(service, " = ")
+				document += fmt.Sprintf("%s = { path = \"/\", runCommand = \"x\", dependsOn = %s }\n", name, dependsOn)
+			}
+
+			_, err := parseDocument(t, document)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("got %v, want an error containing %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestStartOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []Service
+		want     []string
+	}{
+		{
+			name:     "no dependencies keep file order",
+			services: []Service{{Name: "web"}, {Name: "api"}, {Name: "db"}},
+			want:     []string{"web", "api", "db"},
+		},
+		{
+			name: "chain",
+			services: []Service{
+				{Name: "web", DependsOn: []string{"api"}},
+				{Name: "api", DependsOn: []string{"db"}},
+				{Name: "db"},
+			},
+			want: []string{"db", "api", "web"},
+		},
+		{
+			name: "diamond",
+			services: []Service{
+				{Name: "app", DependsOn: []string{"b", "a"}},
+				{Name: "a", DependsOn: []string{"base"}},
+				{Name: "b", DependsOn: []string{"base"}},
+				{Name: "base"},
+			},
+			want: []string{"base", "b", "a", "app"},
+		},
+		{
+			name: "unrelated services stay in place",
+			services: []Service{
+				{Name: "docs"},
+				{Name: "web", DependsOn: []string{"api"}},
+				{Name: "worker"},
+				{Name: "api"},
+			},
+			want: []string{"docs", "api", "web", "worker"},
+		},
+		{
+			// As after Select leaves a dependency out
+			name:     "missing dependency",
+			services: []Service{{Name: "web", DependsOn: []string{"api"}}},
+			want:     []string{"web"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Run it a few times, since the order must not depend on map iteration
+			for i := 0; i < 10; i++ {
+				var names []string
+				for _, service := range StartOrder(tc.services) {
+					names = append(names, service.Name)
+				}
+				if !reflect.DeepEqual(names, tc.want) {
+					t.Fatalf("got %v, want %v", names, tc.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// DeployedEnvFile is the file runtime deploy writes the merged environment to, next to the service's code
+const DeployedEnvFile = ".runtime.env"
+
+// envKeyPattern matches the variable names a shell accepts. Anything else would break
+// the deployed env file, or run as a command when a shell sources it.
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+const invalidEnvKey = "is not a valid variable name; use letters, digits and underscores, not starting with a digit"
+
+// getEnv reads an env table, rejecting names that aren't valid variable names
+func (p *configParser) getEnv(tree *toml.Tree, prefix string) (map[string]string, error) {
+	env, err := p.getStringMap(tree, prefix, "env")
+	if err != nil || env == nil {
+		return env, err
+	}
+
+	table := getValue(tree, "env").(*toml.Tree)
+	for _, name := range orderedKeys(table) {
+		if !envKeyPattern.MatchString(name) {
+			return nil, newKeyError(p.filename, getPosition(table, name), joinKey(joinKey(prefix, "env"), name),
+				"%s", invalidEnvKey)
+		}
+	}
+	return env, nil
+}
+
+// ResolveEnv merges the environment for a service. Later sources win:
+//  1. PORT, when the service sets a fixed port
+//  2. the shared [env] table
+//  3. the service's envFile entries, in the order they are listed
+//  4. the service's own env table
+//
+// envFile paths are relative to the service path, and missing files are skipped
+// so optional files like .env.local don't need to exist.
+func (c Config) ResolveEnv(service Service) (map[string]string, error) {
+	env := map[string]string{}
+	if service.Port != 0 {
+		env["PORT"] = strconv.Itoa(service.Port)
+	}
+
+	for key, value := range c.Env {
+		env[key] = value
+	}
+
+	for _, envFile := range service.EnvFiles {
+		path := envFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(service.Path, path)
+		}
+
+		values, err := LoadEnvFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("service '%s': %w", service.Name, err)
+		}
+
+		for key, value := range values {
+			env[key] = value
+		}
+	}
+
+	for key, value := range service.Env {
+		env[key] = value
+	}
+
+	return env, nil
+}
+
+// LoadEnvFile reads a dotenv-style file of KEY=value lines. Blank lines, # comments
+// and a leading "export " are ignored; values may be single or double quoted.
+func LoadEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	env := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=value", path, lineNumber)
+		}
+		if !envKeyPattern.MatchString(key) {
+			return nil, &ConfigError{File: path, Line: lineNumber, Column: 1, Key: key,
+				Message: invalidEnvKey}
+		}
+
+		env[key] = parseEnvValue(strings.TrimSpace(value))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return env, nil
+}
+
+// parseEnvValue strips quotes and trailing comments from a dotenv value
+func parseEnvValue(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		replacer := strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+		return replacer.Replace(value[1 : len(value)-1])
+	}
+
+	// Unquoted values can carry an inline comment
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		value = strings.TrimSpace(value[:idx])
+	}
+
+	return value
+}
+
+// EnvList returns the environment as sorted KEY=value pairs, the form exec.Cmd expects
+func EnvList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for key, value := range env {
+		list = append(list, key+"="+value)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// FormatEnvFile renders the environment as KEY="value" lines that both
+// systemd's EnvironmentFile and a POSIX shell can read. Newlines are kept as they
+// are, since both read them inside double quotes and neither turns \n back into one.
+func FormatEnvFile(env map[string]string) []byte {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+	var builder strings.Builder
+	for _, pair := range EnvList(env) {
+		key, value, _ := strings.Cut(pair, "=")
+		builder.WriteString(fmt.Sprintf("%s=\"%s\"\n", key, replacer.Replace(value)))
+	}
+
+	return []byte(builder.String())
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFormatEnvFileRoundTrips sources the rendered file with sh, as health checks on
+// an instance do, and checks every value comes back unchanged
+func TestFormatEnvFileRoundTrips(t *testing.T) {
+	env := map[string]string{
+		"PLAIN":     "hello",
+		"SPACES":    "two words",
+		"QUOTES":    `say "hi" and 'bye'`,
+		"SHELL":     "$HOME `id` $(id)",
+		"BACKSLASH": `C:\path\n`,
+		"MULTILINE": "-----BEGIN KEY-----\nabc\ndef\n-----END KEY-----\n",
+	}
+
+	path := filepath.Join(t.TempDir(), DeployedEnvFile)
+	if err := os.WriteFile(path, FormatEnvFile(env), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range env {
+		output, err := exec.Command("sh", "-c", `set -a && . "$1" && printf %s "$`+key+`"`, "sh", path).Output()
+		if err != nil {
+			t.Fatalf("failed to source the env file: %v", err)
+		}
+		if string(output) != want {
+			t.Errorf("%s came back as %q, want %q", key, output, want)
+		}
+	}
+}
+
+// TestInvalidEnvKeysAreRejected checks names that could break the env file or run as
+// shell commands are refused, both in runtime.toml and in envFile files
+func TestInvalidEnvKeysAreRejected(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, key := range []string{`"A;curl x|sh"`, `"A\nB"`, `"1ST"`, `"has space"`, `"DASH-ED"`} {
+		config := filepath.Join(dir, "runtime.toml")
+		document := "[api]\npath = \"/\"\nrunCommand = \"x\"\n\n[api.env]\n" + key + " = \"1\"\n"
+		if err := os.WriteFile(config, []byte(document), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := ParseConfig(config)
+		var configErr *ConfigError
+		if !errors.As(err, &configErr) || configErr.Line != 6 || !strings.Contains(configErr.Message, "not a valid variable name") {
+			t.Errorf("env key %s: got %v, want an invalid name error on line 6", key, err)
+		}
+	}
+
+	envFile := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envFile, []byte("GOOD=1\nA;curl x|sh=2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadEnvFile(envFile)
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Line != 2 || configErr.Key != "A;curl x|sh" {
+		t.Errorf("got %v, want an invalid name error for line 2", err)
+	}
+
+	if _, err := ParseConfig("testdata/every_key.toml"); err != nil {
+		t.Errorf("valid names are rejected: %v", err)
+	}
+}
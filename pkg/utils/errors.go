@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/pelletier/go-toml"
+)
+
+// ConfigError describes a problem found while loading runtime.toml.
+// Line and Column are 1-indexed and zero when the position is unknown.
+type ConfigError struct {
+	File    string
+	Line    int
+	Column  int
+	Key     string // dotted path of the offending key, e.g. "backend.path"
+	Message string
+	Err     error // underlying error, if any
+}
+
+func (e *ConfigError) Error() string {
+	location := e.File
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+
+	if e.Key != "" {
+		return fmt.Sprintf("%s: '%s' %s", location, e.Key, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", location, e.Message)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// tomlErrorRegex matches the "(line, col): message" format go-toml uses for syntax errors
+var tomlErrorRegex = regexp.MustCompile(`^\((\d+), (\d+)\): (.*)$`)
+
+// newSyntaxError converts a go-toml load error into a ConfigError with a position
+func newSyntaxError(filename string, err error) *ConfigError {
+	configErr := &ConfigError{File: filename, Message: err.Error(), Err: err}
+
+	if matches := tomlErrorRegex.FindStringSubmatch(err.Error()); matches != nil {
+		configErr.Line, _ = strconv.Atoi(matches[1])
+		configErr.Column, _ = strconv.Atoi(matches[2])
+		configErr.Message = matches[3]
+	}
+
+	return configErr
+}
+
+// newKeyError builds a ConfigError pointing at the given position
+func newKeyError(filename string, pos toml.Position, key, format string, args ...interface{}) *ConfigError {
+	return &ConfigError{
+		File:    filename,
+		Line:    pos.Line,
+		Column:  pos.Col,
+		Key:     key,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// minDiskSizeGb is the smallest boot disk GCP accepts for its public images
+const minDiskSizeGb = 10
+
+// GCPSettings shape the instances of services with runsOn = "gcp.<machineType>".
+// Empty fields use runtime deploy's defaults.
+type GCPSettings struct {
+	Region     string // picks a zone in the region when Zone is empty
+	Zone       string
+	Image      string // image family, like "debian-12" or "ubuntu-os-cloud/ubuntu-2204-lts", or a full image path
+	DiskSizeGb int
+	DiskType   string // like "pd-standard", "pd-balanced" or "pd-ssd"
+}
+
+// gcpKeys are the settings parseGCPSettings reads
+var gcpKeys = []string{"region", "zone", "image", "diskSizeGb", "diskType"}
+
+// parseGCPSettings reads the GCP settings of a scope: the top level for the whole
+// project, or a service to override them key by key. They can be set as keys of the
+// scope itself or grouped in a gcp table, but not both at once:
+//
+//	region = "europe-west1"
+//	diskType = "pd-balanced"
+//
+//	[api]
+//	diskSizeGb = 50
+//
+//	[worker.gcp]
+//	zone = "europe-west1-c"
+func (p *configParser) parseGCPSettings(tree *toml.Tree, prefix string, defaults GCPSettings) (GCPSettings, error) {
+	table, err := p.getTable(tree, prefix, "gcp")
+	if err != nil {
+		return defaults, err
+	}
+
+	type source struct {
+		tree    *toml.Tree
+		keyPath string
+	}
+	sources := []source{{tree, prefix}}
+	if table != nil {
+		sources = append(sources, source{table, joinKey(prefix, "gcp")})
+	}
+
+	settings := defaults
+	setIn := map[string]source{} // where each key was set in this scope
+	for _, src := range sources {
+		for _, key := range gcpKeys {
+			if !src.tree.Has(key) {
+				continue
+			}
+			if previous, ok := setIn[key]; ok {
+				return GCPSettings{}, newKeyError(p.filename, getPosition(src.tree, key), joinKey(src.keyPath, key),
+					"is already set as '%s'", joinKey(previous.keyPath, key))
+			}
+			setIn[key] = src
+		}
+
+		for _, field := range []struct {
+			key   string
+			value *string
+		}{
+			{"region", &settings.Region},
+			{"zone", &settings.Zone},
+			{"image", &settings.Image},
+			{"diskType", &settings.DiskType},
+		} {
+			value, found, err := p.getString(src.tree, src.keyPath, field.key)
+			if err != nil {
+				return GCPSettings{}, err
+			}
+			if found {
+				*field.value = value
+			}
+		}
+
+		if settings.DiskSizeGb, err = p.getInt(src.tree, src.keyPath, "diskSizeGb", settings.DiskSizeGb); err != nil {
+			return GCPSettings{}, err
+		}
+		if src.tree.Has("diskSizeGb") && settings.DiskSizeGb < minDiskSizeGb {
+			return GCPSettings{}, newKeyError(p.filename, getPosition(src.tree, "diskSizeGb"), joinKey(src.keyPath, "diskSizeGb"),
+				"must be at least %d, got %d", minDiskSizeGb, settings.DiskSizeGb)
+		}
+	}
+
+	// A zone is named after its region, like us-central1-a in us-central1. A region or
+	// zone set here replaces an inherited one it doesn't fit with.
+	zoneSource, zoneSet := setIn["zone"]
+	_, regionSet := setIn["region"]
+	if settings.Region != "" && settings.Zone != "" && !strings.HasPrefix(settings.Zone, settings.Region+"-") {
+		switch {
+		case zoneSet && regionSet:
+			return GCPSettings{}, newKeyError(p.filename, getPosition(zoneSource.tree, "zone"), joinKey(zoneSource.keyPath, "zone"),
+				"is '%s', which is not in region '%s'", settings.Zone, settings.Region)
+		case zoneSet:
+			settings.Region = ""
+		default:
+			settings.Zone = ""
+		}
+	}
+
+	return settings, nil
+}
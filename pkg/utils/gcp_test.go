@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml"
+)
+
+func TestParseGCPSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     GCPSettings // of the service "api"
+		err      string
+	}{
+		{
+			name:     "flat keys are inherited and overridden key by key",
+			document: "region = \"europe-west1\"\ndiskType = \"pd-ssd\"\n[api]\ndiskSizeGb = 50\nregion = \"us-east1\"",
+			want:     GCPSettings{Region: "us-east1", DiskType: "pd-ssd", DiskSizeGb: 50},
+		},
+		{
+			name:     "gcp tables work like flat keys",
+			document: "[gcp]\nimage = \"debian-12\"\n[api.gcp]\nzone = \"us-east1-b\"",
+			want:     GCPSettings{Zone: "us-east1-b", Image: "debian-12"},
+		},
+		{
+			name:     "a flat key overrides the project's gcp table",
+			document: "[gcp]\nimage = \"debian-12\"\n[api]\nimage = \"debian-11\"",
+			want:     GCPSettings{Image: "debian-11"},
+		},
+		{
+			name:     "a service zone replaces the project region it isn't in",
+			document: "region = \"europe-west1\"\n[api]\nzone = \"us-east1-b\"",
+			want:     GCPSettings{Zone: "us-east1-b"},
+		},
+		{
+			name:     "one key set both ways",
+			document: "[api]\nzone = \"us-east1-b\"\n[api.gcp]\nzone = \"us-east1-c\"",
+			err:      "'api.gcp.zone' is already set as 'api.zone'",
+		},
+		{
+			name:     "zone outside the region of the same scope",
+			document: "[api]\nregion = \"europe-west1\"\n[api.gcp]\nzone = \"us-east1-b\"",
+			err:      "'api.gcp.zone' is 'us-east1-b', which is not in region 'europe-west1'",
+		},
+		{
+			name:     "disk too small",
+			document: "diskSizeGb = 5",
+			err:      "'diskSizeGb' must be at least 10, got 5",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := toml.Load(tc.document)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p := &configParser{filename: "runtime.toml"}
+			settings, err := p.parseGCPSettings(tree, "", GCPSettings{})
+			if err == nil {
+				if api, ok := tree.Get("api").(*toml.Tree); ok {
+					settings, err = p.parseGCPSettings(api, "api", settings)
+				}
+			}
+
+			switch {
+			case tc.err != "":
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("got error %v, want %q", err, tc.err)
+				}
+			case err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case settings != tc.want:
+				t.Errorf("got %+v, want %+v", settings, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// MatchGlob reports whether a slash-separated relative path matches a glob pattern.
+// Segments use path.Match syntax, and a "**" segment matches any number of
+// directories, so "**/*.go" matches Go files at any depth.
+func MatchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// validGlob reports whether every segment of a pattern is valid path.Match syntax
+func validGlob(pattern string) bool {
+	if pattern == "" || strings.HasPrefix(pattern, "/") {
+		return false
+	}
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// getGlobs reads a list of relative glob patterns, such as watch = ["**/*.go"]
+func (p *configParser) getGlobs(tree *toml.Tree, prefix, key string) ([]string, error) {
+	patterns, err := p.getStringSlice(tree, prefix, key)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range patterns {
+		if !validGlob(pattern) {
+			return nil, newKeyError(p.filename, getPosition(tree, key), joinKey(prefix, key),
+				"contains an invalid pattern '%s'; use relative globs like \"**/*.go\"", pattern)
+		}
+	}
+
+	return patterns, nil
+}
@@ -0,0 +1,59 @@
+package utils
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"cmd/*.go", "cmd/main.go", true},
+		{"cmd/*.go", "cmd/sub/main.go", false},
+
+		// ** matches any number of directories, including none
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/api/main.go", true},
+		{"**/*.go", "main.go.orig", false},
+		{"src/**", "src", true},
+		{"src/**", "src/a/b/c.ts", true},
+		{"src/**", "srcs/a.ts", false},
+		{"src/**/test/*.ts", "src/test/a.ts", true},
+		{"src/**/test/*.ts", "src/a/b/test/a.ts", true},
+		{"src/**/test/*.ts", "src/a/b/tests/a.ts", false},
+		{"**", "anything/at/all", true},
+		{"**/node_modules/**", "web/node_modules/react/index.js", true},
+
+		// Segments use path.Match syntax
+		{"*.{js,ts}", "a.ts", false},
+		{"*.[jt]s", "a.ts", true},
+		{"?.go", "a.go", true},
+		{"?.go", "ab.go", false},
+	}
+
+	for _, tc := range tests {
+		if got := MatchGlob(tc.pattern, tc.name); got != tc.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestValidGlob(t *testing.T) {
+	tests := map[string]bool{
+		"**/*.go":   true,
+		"src/*.ts":  true,
+		"*.[jt]s":   true,
+		"":          false,
+		"/abs/*.go": false,
+		"[unclosed": false,
+		"a/[b/c":    false,
+	}
+
+	for pattern, want := range tests {
+		if got := validGlob(pattern); got != want {
+			t.Errorf("validGlob(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
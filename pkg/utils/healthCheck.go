@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+)
+
+const (
+	HealthCheckTCP     = "tcp"
+	HealthCheckHTTP    = "http"
+	HealthCheckCommand = "command"
+)
+
+// HealthCheck describes how to tell that a service is ready
+type HealthCheck struct {
+	Type     string // tcp, http or command
+	Target   string // host:port, URL or shell command depending on Type
+	Interval time.Duration
+	Timeout  time.Duration
+	Retries  int
+}
+
+// Defaults used when a health check leaves them out
+const (
+	defaultHealthInterval = 2 * time.Second
+	defaultHealthTimeout  = 2 * time.Second
+	defaultHealthRetries  = 30
+)
+
+// parseHealthCheck reads an optional healthCheck table such as
+// healthCheck = { http = "http://localhost:8000/health", interval = "1s" }
+func (p *configParser) parseHealthCheck(svc *toml.Tree, prefix string) (*HealthCheck, error) {
+	value := getValue(svc, "healthCheck")
+	if value == nil {
+		return nil, nil
+	}
+
+	keyPath := joinKey(prefix, "healthCheck")
+	table, ok := value.(*toml.Tree)
+	if !ok {
+		return nil, newKeyError(p.filename, getPosition(svc, "healthCheck"), keyPath,
+			"must be a table, got %s", describeType(value))
+	}
+
+	check := &HealthCheck{
+		Interval: defaultHealthInterval,
+		Timeout:  defaultHealthTimeout,
+		Retries:  defaultHealthRetries,
+	}
+
+	// Exactly one of tcp, http or command picks the kind of check
+	var kinds []string
+	for _, kind := range []string{HealthCheckTCP, HealthCheckHTTP, HealthCheckCommand} {
+		if table.Has(kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) != 1 {
+		return nil, newKeyError(p.filename, table.Position(), keyPath,
+			"must set exactly one of 'tcp', 'http' or 'command'")
+	}
+	check.Type = kinds[0]
+
+	switch check.Type {
+	case HealthCheckTCP:
+		// A bare port number means localhost
+		switch target := getValue(table, HealthCheckTCP).(type) {
+		case int64:
+			check.Target = net.JoinHostPort("localhost", strconv.FormatInt(target, 10))
+		case string:
+			if _, _, err := net.SplitHostPort(target); err != nil {
+				if _, convErr := strconv.Atoi(target); convErr != nil {
+					return nil, newKeyError(p.filename, getPosition(table, HealthCheckTCP), joinKey(keyPath, HealthCheckTCP),
+						"must be a port or host:port, got '%s'", target)
+				}
+				target = net.JoinHostPort("localhost", target)
+			}
+			check.Target = target
+		default:
+			return nil, newKeyError(p.filename, getPosition(table, HealthCheckTCP), joinKey(keyPath, HealthCheckTCP),
+				"must be a port or host:port, got %s", describeType(target))
+		}
+
+	case HealthCheckHTTP:
+		target, _, err := p.getString(table, keyPath, HealthCheckHTTP)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+			return nil, newKeyError(p.filename, getPosition(table, HealthCheckHTTP), joinKey(keyPath, HealthCheckHTTP),
+				"must be an http:// or https:// URL, got '%s'", target)
+		}
+		check.Target = target
+
+	case HealthCheckCommand:
+		target, _, err := p.getString(table, keyPath, HealthCheckCommand)
+		if err != nil {
+			return nil, err
+		}
+		check.Target = target
+	}
+
+	var err error
+	if check.Interval, err = p.getDuration(table, keyPath, "interval", check.Interval); err != nil {
+		return nil, err
+	}
+	if check.Timeout, err = p.getDuration(table, keyPath, "timeout", check.Timeout); err != nil {
+		return nil, err
+	}
+	if check.Retries, err = p.getInt(table, keyPath, "retries", check.Retries); err != nil {
+		return nil, err
+	}
+
+	return check, nil
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// HostRunsOnPrefix is the runsOn prefix for services deployed to a server from
+// [hosts], as in runsOn = "ssh.web1"
+const HostRunsOnPrefix = "ssh."
+
+// Host is a server of your own that runtime deploy reaches over SSH
+type Host struct {
+	Name    string
+	Address string // hostname or IP
+	User    string // SSH user; empty leaves it to ssh and ~/.ssh/config
+	Port    int    // SSH port; 0 for the default
+}
+
+// parseHosts reads the optional [hosts] section:
+//
+//	[hosts.web1]
+//	address = "10.0.0.5"
+//	user = "deploy"
+//	port = 22
+func (p *configParser) parseHosts(tree *toml.Tree) (map[string]Host, error) {
+	table, err := p.getTable(tree, "", "hosts")
+	if err != nil || table == nil {
+		return nil, err
+	}
+
+	hosts := map[string]Host{}
+	for _, name := range orderedKeys(table) {
+		keyPath := joinKey("hosts", name)
+		hostTable, err := p.getTable(table, "hosts", name)
+		if err != nil {
+			return nil, err
+		}
+
+		host := Host{Name: name}
+		var found bool
+		if host.Address, found, err = p.getString(hostTable, keyPath, "address"); err != nil {
+			return nil, err
+		}
+		if !found || host.Address == "" {
+			return nil, newKeyError(p.filename, hostTable.Position(), keyPath,
+				"is missing required field 'address'")
+		}
+		if host.User, _, err = p.getString(hostTable, keyPath, "user"); err != nil {
+			return nil, err
+		}
+		if host.Port, err = p.getInt(hostTable, keyPath, "port", 0); err != nil {
+			return nil, err
+		}
+		if hostTable.Has("port") && !validPort(int64(host.Port)) {
+			return nil, newKeyError(p.filename, getPosition(hostTable, "port"), joinKey(keyPath, "port"),
+				"must be between 1 and 65535, got %d", host.Port)
+		}
+
+		hosts[name] = host
+	}
+
+	return hosts, nil
+}
+
+// checkHosts reports runsOn = "ssh.<name>" values that name no host in [hosts]
+func (p *configParser) checkHosts(entries []serviceEntry, services []Service, hosts map[string]Host) {
+	for i, service := range services {
+		if !strings.HasPrefix(service.RunsOn, HostRunsOnPrefix) {
+			continue
+		}
+
+		name := strings.TrimPrefix(service.RunsOn, HostRunsOnPrefix)
+		if _, ok := hosts[name]; ok {
+			continue
+		}
+
+		names := make([]string, 0, len(hosts))
+		for known := range hosts {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+
+		position, keyPath := getPosition(entries[i].tree, "runsOn"), joinKey(entries[i].keyPath, "runsOn")
+		if suggestion, ok := ClosestMatch(name, names); ok {
+			p.report(newKeyError(p.filename, position, keyPath,
+				"references unknown host '%s', did you mean '%s%s'?", name, HostRunsOnPrefix, suggestion))
+			continue
+		}
+		p.report(newKeyError(p.filename, position, keyPath,
+			"references unknown host '%s'; add a [hosts.%s] table with its address", name, name))
+	}
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"path/filepath"
+	"regexp"
+
+	"github.com/pelletier/go-toml"
+)
+
+// Layout customises how runtime dev arranges services into tabs
+type Layout struct {
+	Tabs  []LayoutTab           // tabs holding several panes, in file order
+	Panes map[string]LayoutPane // extra panes that aren't services, by name
+}
+
+// LayoutTab groups services and extra panes into one tab, e.g. [layout.tabs.api]
+type LayoutTab struct {
+	Name  string
+	Panes []string // service or extra pane names
+	Split string   // "vertical" (side by side) or "horizontal" (stacked)
+	Sizes []string // optional size per pane, like "60%" or a number of lines/columns
+}
+
+// LayoutPane is an extra pane such as a scratch shell or a git status watcher
+type LayoutPane struct {
+	Name    string
+	Command string // empty for an interactive shell
+	Path    string // absolute working directory
+}
+
+const (
+	SplitVertical   = "vertical"
+	SplitHorizontal = "horizontal"
+)
+
+var paneSizeRegex = regexp.MustCompile(`^[1-9][0-9]*%?$`)
+
+// TabFor returns the layout tab a pane belongs to, if any
+func (l Layout) TabFor(pane string) (LayoutTab, bool) {
+	for _, tab := range l.Tabs {
+		for _, name := range tab.Panes {
+			if name == pane {
+				return tab, true
+			}
+		}
+	}
+	return LayoutTab{}, false
+}
+
+// parseLayout reads the optional [layout] section
+func (p *configParser) parseLayout(tree *toml.Tree, services []Service, configDir string) (Layout, error) {
+	layout := Layout{Panes: map[string]LayoutPane{}}
+
+	value := getValue(tree, "layout")
+	if value == nil {
+		return layout, nil
+	}
+
+	table, ok := value.(*toml.Tree)
+	if !ok {
+		return Layout{}, newKeyError(p.filename, getPosition(tree, "layout"), "layout",
+			"must be a table, got %s", describeType(value))
+	}
+
+	isService := map[string]bool{}
+	for _, service := range services {
+		isService[service.Name] = true
+	}
+
+	// Extra panes first, so tabs can reference them
+	panesTable, err := p.getTable(table, "layout", "panes")
+	if err != nil {
+		return Layout{}, err
+	}
+	if panesTable != nil {
+		for _, name := range orderedKeys(panesTable) {
+			keyPath := joinKey("layout.panes", name)
+			paneTree, err := p.getTable(panesTable, "layout.panes", name)
+			if err != nil {
+				return Layout{}, err
+			}
+			if isService[name] {
+				return Layout{}, newKeyError(p.filename, paneTree.Position(), keyPath,
+					"has the same name as a service")
+			}
+
+			pane := LayoutPane{Name: name, Path: configDir}
+			if pane.Command, _, err = p.getString(paneTree, keyPath, "command"); err != nil {
+				return Layout{}, err
+			}
+			path, ok, err := p.getString(paneTree, keyPath, "path")
+			if err != nil {
+				return Layout{}, err
+			}
+			if ok {
+				pane.Path = filepath.Join(configDir, path)
+			}
+
+			layout.Panes[name] = pane
+		}
+	}
+
+	tabsTable, err := p.getTable(table, "layout", "tabs")
+	if err != nil {
+		return Layout{}, err
+	}
+	if tabsTable == nil {
+		return layout, nil
+	}
+
+	placed := map[string]string{}
+	for _, name := range orderedKeys(tabsTable) {
+		keyPath := joinKey("layout.tabs", name)
+		tabTree, err := p.getTable(tabsTable, "layout.tabs", name)
+		if err != nil {
+			return Layout{}, err
+		}
+
+		tab := LayoutTab{Name: name, Split: SplitVertical}
+		if tab.Panes, err = p.getStringSlice(tabTree, keyPath, "panes"); err != nil {
+			return Layout{}, err
+		}
+		if len(tab.Panes) == 0 {
+			return Layout{}, newKeyError(p.filename, tabTree.Position(), keyPath,
+				"must list at least one pane in 'panes'")
+		}
+
+		for _, pane := range tab.Panes {
+			if _, isPane := layout.Panes[pane]; !isService[pane] && !isPane {
+				return Layout{}, newKeyError(p.filename, getPosition(tabTree, "panes"), joinKey(keyPath, "panes"),
+					"references '%s', which is neither a service nor a pane in [layout.panes]", pane)
+			}
+			if other, exists := placed[pane]; exists {
+				return Layout{}, newKeyError(p.filename, getPosition(tabTree, "panes"), joinKey(keyPath, "panes"),
+					"places '%s', which is already in tab '%s'", pane, other)
+			}
+			placed[pane] = name
+		}
+
+		split, ok, err := p.getString(tabTree, keyPath, "split")
+		if err != nil {
+			return Layout{}, err
+		}
+		if ok {
+			if split != SplitVertical && split != SplitHorizontal {
+				return Layout{}, newKeyError(p.filename, getPosition(tabTree, "split"), joinKey(keyPath, "split"),
+					"must be \"vertical\" or \"horizontal\", got '%s'", split)
+			}
+			tab.Split = split
+		}
+
+		if tab.Sizes, err = p.getStringSlice(tabTree, keyPath, "sizes"); err != nil {
+			return Layout{}, err
+		}
+		if tab.Sizes != nil && len(tab.Sizes) != len(tab.Panes) {
+			return Layout{}, newKeyError(p.filename, getPosition(tabTree, "sizes"), joinKey(keyPath, "sizes"),
+				"must have one entry per pane (%d), got %d", len(tab.Panes), len(tab.Sizes))
+		}
+		for _, size := range tab.Sizes {
+			if !paneSizeRegex.MatchString(size) {
+				return Layout{}, newKeyError(p.filename, getPosition(tabTree, "sizes"), joinKey(keyPath, "sizes"),
+					"must contain percentages like \"60%%\" or whole numbers, got '%s'", size)
+			}
+		}
+
+		layout.Tabs = append(layout.Tabs, tab)
+	}
+
+	return layout, nil
+}
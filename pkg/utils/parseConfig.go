@@ -1,102 +1,498 @@
 package utils
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
+	"time"
 
 	"github.com/pelletier/go-toml"
 )
 
 type Service struct {
-	Name    string
-	Path    string
-	Command string
-	RunsOn  string
+	Name        string
+	Path        string
+	Command     string
+	RunsOn      string
+	Env         map[string]string // env table, highest precedence
+	EnvFiles    []string          // envFile entries, relative to Path
+	DependsOn   []string          // services that must be started first
+	HealthCheck *HealthCheck      // optional readiness check
+	Restart     RestartPolicy     // what to do when the service exits on its own
+	Watch       []string          // globs, relative to Path, whose changes restart the service in runtime dev
+	Ignore      []string          // globs excluded from Watch
+	Port        int               // exported to the service as PORT; 0 if unset or automatic
+	AutoPort    bool              // port = "auto": runtime dev picks a free port for each session
+	Ports       []int             // every fixed port the service listens on, from port and ports
+	GCP         GCPSettings       // the project's GCP settings with the service's own applied
 }
 
 type Config struct {
-	Name     string
-	Services []Service
+	Name        string
+	Dir         string // absolute directory containing runtime.toml
+	Services    []Service
+	Env         map[string]string // shared [env] table applied to every service
+	Profiles    map[string]Profile
+	Multiplexer string // preferred terminal multiplexer for runtime dev, "zellij" or "tmux"
+	Layout      Layout
+	Proxy       *Proxy          // nil unless runtime.toml has a [proxy] section
+	Hosts       map[string]Host // servers from [hosts], by name, for runsOn = "ssh.<name>"
+	GCP         GCPSettings     // project-wide GCP settings
 }
 
-func ParseConfig(filename string) Config {
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		fmt.Printf("❌ %s not found\n", filename)
-		return Config{}
+// Multiplexers are the terminal multiplexers runtime dev can use
+var Multiplexers = []string{"zellij", "tmux"}
+
+// ParseConfig loads runtime.toml and returns the project config.
+// Every failure is returned as a *ConfigError pointing at the offending key.
+func ParseConfig(filename string) (Config, error) {
+	if _, err := os.Stat(filename); err != nil {
+		if os.IsNotExist(err) {
+			return Config{}, &ConfigError{File: filename, Message: "file not found", Err: err}
+		}
+		return Config{}, &ConfigError{File: filename, Message: err.Error(), Err: err}
 	}
 
-	tree, err := toml.LoadFile(filename)
+	tree, err := loadTree(filename)
 	if err != nil {
-		fmt.Printf("❌ Failed to parse config: %v\n", err)
-		return Config{}
+		return Config{}, newSyntaxError(filename, err)
+	}
+
+	return parseTree(filename, tree)
+}
+
+// parseTree builds the config from a loaded document, failing with the first problem found
+func parseTree(filename string, tree *toml.Tree) (Config, error) {
+	p := &configParser{filename: filename}
+	config := p.parse(tree)
+	if len(p.problems) > 0 {
+		return Config{}, p.problems[0]
 	}
+	return config, nil
+}
 
-	configDir, _ := filepath.Abs(filepath.Dir(filename))
+// parse reads the whole document. Problems are recorded rather than returned, so one
+// mistake doesn't hide the others; runtime validate reports them all.
+func (p *configParser) parse(tree *toml.Tree) Config {
+	configDir, _ := filepath.Abs(filepath.Dir(p.filename))
 	services := []Service{}
 
 	// Get the project name from the TOML
-	var projectName string
-	if nameValue := tree.Get("name"); nameValue != nil {
-		projectName = nameValue.(string)
+	projectName, _, err := p.getString(tree, "", "name")
+	p.report(err)
+
+	multiplexer, _, err := p.getString(tree, "", "multiplexer")
+	p.report(err)
+	if multiplexer != "" && !contains(Multiplexers, multiplexer) {
+		p.report(newKeyError(p.filename, getPosition(tree, "multiplexer"), "multiplexer",
+			"must be \"zellij\" or \"tmux\", got '%s'", multiplexer))
 	}
 
-	// Get service order from file
-	serviceOrder, err := getServiceOrder(filename)
-	if err != nil {
-		fmt.Printf("❌ Failed to get service order: %v\n", err)
-		return Config{}
-	}
-
-	// Process services in the order they appear in the file
-	for _, key := range serviceOrder {
-		svc := tree.Get(key).(*toml.Tree)
-		path := svc.Get("path")
-		cmd := svc.Get("runCommand")
-		runsOn := svc.Get("runsOn")
-
-		if path != nil && cmd != nil {
-			service := Service{
-				Name:    key,
-				Path:    filepath.Join(configDir, path.(string)),
-				Command: cmd.(string),
+	// Shared environment applied to every service
+	sharedEnv, err := p.getEnv(tree, "")
+	p.report(err)
+
+	// Instance settings for GCP, which services can override
+	gcp, err := p.parseGCPSettings(tree, "", GCPSettings{})
+	p.report(err)
+
+	// Collect service tables in the order they appear in the file
+	entries, problems := p.serviceEntries(tree)
+	p.problems = append(p.problems, problems...)
+
+	// A service with problems is still kept, so references to it don't add more
+	for _, entry := range entries {
+		service := p.parseService(entry.tree, entry.name, entry.keyPath, configDir)
+		service.GCP, err = p.parseGCPSettings(entry.tree, entry.keyPath, gcp)
+		p.report(err)
+
+		services = append(services, service)
+	}
+
+	p.checkDependencies(entries, services)
+	p.checkPorts(entries, services)
+
+	profiles, err := p.parseProfiles(tree, services)
+	p.report(err)
+
+	layout, err := p.parseLayout(tree, services, configDir)
+	p.report(err)
+
+	proxy, err := p.parseProxy(tree, services)
+	p.report(err)
+
+	// Without a valid [hosts] every runsOn = "ssh.<name>" would look unknown
+	hosts, err := p.parseHosts(tree)
+	if !p.report(err) {
+		p.checkHosts(entries, services, hosts)
+	}
+
+	return Config{
+		Name:        projectName,
+		Dir:         configDir,
+		Services:    services,
+		Env:         sharedEnv,
+		Profiles:    profiles,
+		Multiplexer: multiplexer,
+		Layout:      layout,
+		Proxy:       proxy,
+		Hosts:       hosts,
+		GCP:         gcp,
+	}
+}
+
+// reservedSections are top-level tables that configure runtime itself rather than define a service
+var reservedSections = map[string]bool{
+	"services": true,
+	"env":      true,
+	"profiles": true,
+	"layout":   true,
+	"proxy":    true,
+	"hosts":    true,
+	"gcp":      true,
+}
+
+// misplacedService reports whether a reserved section holds what looks like a service,
+// such as [proxy] with a runCommand, which has to move under [services]. [env] may
+// well define a variable called path, so there only runCommand gives a service away.
+func misplacedService(name string, section *toml.Tree) bool {
+	if name == "services" || !reservedSections[name] {
+		return false
+	}
+	return section.Has("runCommand") || (name != "env" && section.Has("path"))
+}
+
+// misplacedServiceError reports a service written as one of runtime's own sections
+func (p *configParser) misplacedServiceError(tree *toml.Tree, name string) *ConfigError {
+	return newKeyError(p.filename, getPosition(tree, name), name,
+		"is a reserved section; move the service under [services], as [services.%s]", name)
+}
+
+// serviceEntry is a service table found in the document
+type serviceEntry struct {
+	name    string
+	keyPath string // dotted path used in error messages, e.g. "services.api"
+	tree    *toml.Tree
+}
+
+// serviceEntries returns every service table in definition order, and problems with
+// the tables that couldn't be used. Services can be declared as top-level tables
+// ([api]) or under the services namespace ([services.api]).
+func (p *configParser) serviceEntries(tree *toml.Tree) ([]serviceEntry, []*ConfigError) {
+	var entries []serviceEntry
+	var problems []*ConfigError
+	seen := map[string]string{}
+
+	add := func(parent *toml.Tree, name, keyPath string) {
+		svc, ok := getValue(parent, name).(*toml.Tree)
+		if !ok {
+			problems = append(problems, newKeyError(p.filename, getPosition(parent, name), keyPath,
+				"must be a table, got %s", describeType(getValue(parent, name))))
+			return
+		}
+		if previous, exists := seen[name]; exists {
+			problems = append(problems, newKeyError(p.filename, getPosition(parent, name), keyPath,
+				"duplicates service '%s'", previous))
+			return
+		}
+
+		seen[name] = keyPath
+		entries = append(entries, serviceEntry{name: name, keyPath: keyPath, tree: svc})
+	}
+
+	for _, key := range orderedKeys(tree) {
+		if key == "services" {
+			namespace, ok := getValue(tree, key).(*toml.Tree)
+			if !ok {
+				problems = append(problems, newKeyError(p.filename, getPosition(tree, key), key,
+					"must be a table, got %s", describeType(getValue(tree, key))))
+				continue
 			}
 
-			// Handle optional runsOn field (no validation here)
-			if runsOn != nil {
-				service.RunsOn = runsOn.(string)
+			for _, name := range orderedKeys(namespace) {
+				add(namespace, name, joinKey(key, name))
 			}
+			continue
+		}
 
-			services = append(services, service)
+		// Top-level scalars (like name) and runtime's own sections are never services
+		if reservedSections[key] {
+			if section, ok := getValue(tree, key).(*toml.Tree); ok && misplacedService(key, section) {
+				problems = append(problems, p.misplacedServiceError(tree, key))
+			}
+			continue
+		}
+		switch getValue(tree, key).(type) {
+		case *toml.Tree, []*toml.Tree:
+			add(tree, key, key)
 		}
 	}
 
-	return Config{projectName, services}
+	// Namespaced and top-level services may be interleaved, so order them by position
+	sort.SliceStable(entries, func(i, j int) bool {
+		return positionLess(entries[i].tree.Position(), entries[j].tree.Position())
+	})
+
+	return entries, problems
 }
 
-// getServiceOrder scans the TOML file and returns service names in definition order
-func getServiceOrder(filename string) ([]string, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
+// orderedKeys returns the keys of a table in the order they appear in the document
+func orderedKeys(tree *toml.Tree) []string {
+	keys := tree.Keys()
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := getPosition(tree, keys[i]), getPosition(tree, keys[j])
+		if pi == pj {
+			return keys[i] < keys[j]
+		}
+		return positionLess(pi, pj)
+	})
+	return keys
+}
+
+func positionLess(a, b toml.Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
 	}
-	defer file.Close()
+	return a.Col < b.Col
+}
 
-	var serviceOrder []string
-	scanner := bufio.NewScanner(file)
-	sectionRegex := regexp.MustCompile(`^\[([^\]]+)\]`)
+// keyRead, when set, is called for every key the parser looks up. Tests use it to
+// check every key read is described in configSchema.
+var keyRead func(tree *toml.Tree, key string)
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if matches := sectionRegex.FindStringSubmatch(line); matches != nil {
-			serviceName := matches[1]
-			if serviceName != "name" { // skip the global name section
-				serviceOrder = append(serviceOrder, serviceName)
-			}
+// getValue looks up a single key without splitting it on dots, so quoted keys work
+func getValue(tree *toml.Tree, key string) interface{} {
+	if keyRead != nil {
+		keyRead(tree, key)
+	}
+	return tree.GetPath([]string{key})
+}
+
+// getPosition returns the position of a single key without splitting it on dots
+func getPosition(tree *toml.Tree, key string) toml.Position {
+	return tree.GetPositionPath([]string{key})
+}
+
+// configParser carries the file name so every error can point back at it, and
+// collects the problems found so far
+type configParser struct {
+	filename string
+	problems []*ConfigError
+}
+
+// report records a problem, if there is one, and says whether there was
+func (p *configParser) report(err error) bool {
+	if err == nil {
+		return false
+	}
+	p.problems = append(p.problems, asConfigError(p.filename, err))
+	return true
+}
+
+// parseService reads a single service table. Keys read here must also be described
+// in serviceSchema, or runtime validate reports them as unknown; TestSchemaMatchesParser
+// fails when they drift apart.
+func (p *configParser) parseService(svc *toml.Tree, name, keyPath, configDir string) Service {
+	service := Service{Name: name}
+
+	path, ok, err := p.getString(svc, keyPath, "path")
+	p.report(err)
+	if !ok && err == nil {
+		p.report(newKeyError(p.filename, svc.Position(), keyPath, "is missing required key 'path'"))
+	}
+	service.Path = filepath.Join(configDir, path)
+
+	service.Command, ok, err = p.getString(svc, keyPath, "runCommand")
+	p.report(err)
+	if !ok && err == nil {
+		p.report(newKeyError(p.filename, svc.Position(), keyPath, "is missing required key 'runCommand'"))
+	}
+
+	// Handle optional runsOn field (no validation here)
+	service.RunsOn, _, err = p.getString(svc, keyPath, "runsOn")
+	p.report(err)
+
+	service.Env, err = p.getEnv(svc, keyPath)
+	p.report(err)
+
+	service.EnvFiles, err = p.getStringSlice(svc, keyPath, "envFile")
+	p.report(err)
+
+	service.DependsOn, err = p.getStringSlice(svc, keyPath, "dependsOn")
+	p.report(err)
+
+	service.HealthCheck, err = p.parseHealthCheck(svc, keyPath)
+	p.report(err)
+
+	service.Restart, err = p.parseRestartPolicy(svc, keyPath)
+	p.report(err)
+
+	service.Watch, err = p.getGlobs(svc, keyPath, "watch")
+	p.report(err)
+	service.Ignore, err = p.getGlobs(svc, keyPath, "ignore")
+	p.report(err)
+
+	p.report(p.parsePorts(svc, keyPath, &service))
+
+	return service
+}
+
+// getString returns the string at key, whether it was set, and a type error if it isn't a string
+func (p *configParser) getString(tree *toml.Tree, prefix, key string) (string, bool, error) {
+	value := getValue(tree, key)
+	if value == nil {
+		return "", false, nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return "", false, newKeyError(p.filename, getPosition(tree, key), joinKey(prefix, key),
+			"must be a string, got %s", describeType(value))
+	}
+
+	return str, true, nil
+}
+
+// getTable returns the table at key, or nil if it isn't set
+func (p *configParser) getTable(tree *toml.Tree, prefix, key string) (*toml.Tree, error) {
+	value := getValue(tree, key)
+	if value == nil {
+		return nil, nil
+	}
+
+	table, ok := value.(*toml.Tree)
+	if !ok {
+		return nil, newKeyError(p.filename, getPosition(tree, key), joinKey(prefix, key),
+			"must be a table, got %s", describeType(value))
+	}
+
+	return table, nil
+}
+
+// getStringSlice reads an array of strings; a single string is treated as a one-element array
+func (p *configParser) getStringSlice(tree *toml.Tree, prefix, key string) ([]string, error) {
+	value := getValue(tree, key)
+	if value == nil {
+		return nil, nil
+	}
+
+	if str, ok := value.(string); ok {
+		return []string{str}, nil
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, newKeyError(p.filename, getPosition(tree, key), joinKey(prefix, key),
+			"must be an array of strings, got %s", describeType(value))
+	}
+
+	result := make([]string, 0, len(items))
+	for i, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, newKeyError(p.filename, getPosition(tree, key), fmt.Sprintf("%s[%d]", joinKey(prefix, key), i),
+				"must be a string, got %s", describeType(item))
+		}
+		result = append(result, str)
+	}
+
+	return result, nil
+}
+
+// getStringMap reads a table of scalar values, such as env = { KEY = "value" }.
+// Numbers and booleans are converted to strings since they usually end up in the environment.
+func (p *configParser) getStringMap(tree *toml.Tree, prefix, key string) (map[string]string, error) {
+	value := getValue(tree, key)
+	if value == nil {
+		return nil, nil
+	}
+
+	table, ok := value.(*toml.Tree)
+	if !ok {
+		return nil, newKeyError(p.filename, getPosition(tree, key), joinKey(prefix, key),
+			"must be a table, got %s", describeType(value))
+	}
+
+	result := map[string]string{}
+	for _, name := range table.Keys() {
+		switch item := getValue(table, name).(type) {
+		case string:
+			result[name] = item
+		case int64, uint64, float64, bool:
+			result[name] = fmt.Sprint(item)
+		default:
+			return nil, newKeyError(p.filename, getPosition(table, name), joinKey(joinKey(prefix, key), name),
+				"must be a string, got %s", describeType(item))
 		}
 	}
 
-	return serviceOrder, scanner.Err()
+	return result, nil
+}
+
+// getDuration reads a duration written as a string ("500ms", "2s") or a number of seconds
+func (p *configParser) getDuration(tree *toml.Tree, prefix, key string, def time.Duration) (time.Duration, error) {
+	switch value := getValue(tree, key).(type) {
+	case nil:
+		return def, nil
+	case int64:
+		return time.Duration(value) * time.Second, nil
+	case string:
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			return 0, newKeyError(p.filename, getPosition(tree, key), joinKey(prefix, key),
+				"must be a duration like \"2s\" or \"500ms\", got '%s'", value)
+		}
+		return duration, nil
+	default:
+		return 0, newKeyError(p.filename, getPosition(tree, key), joinKey(prefix, key),
+			"must be a duration like \"2s\", got %s", describeType(value))
+	}
+}
+
+// getInt reads a non-negative integer
+func (p *configParser) getInt(tree *toml.Tree, prefix, key string, def int) (int, error) {
+	switch value := getValue(tree, key).(type) {
+	case nil:
+		return def, nil
+	case int64:
+		if value < 0 {
+			return 0, newKeyError(p.filename, getPosition(tree, key), joinKey(prefix, key),
+				"must not be negative")
+		}
+		return int(value), nil
+	default:
+		return 0, newKeyError(p.filename, getPosition(tree, key), joinKey(prefix, key),
+			"must be an integer, got %s", describeType(value))
+	}
+}
+
+// joinKey builds a dotted key path for error messages
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// describeType names a TOML value's type for error messages
+func describeType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case int64, uint64:
+		return "integer"
+	case float64:
+		return "float"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case *toml.Tree:
+		return "table"
+	case []*toml.Tree:
+		return "array of tables"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
 }
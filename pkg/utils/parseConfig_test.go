@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestServicesKeepFileOrder checks services and layout tabs come out in the order
+// runtime.toml lists them, however they are written
+func TestServicesKeepFileOrder(t *testing.T) {
+	tests := []struct {
+		file     string
+		services []string
+		tabs     []string
+	}{
+		{file: "testdata/order/inline_services.toml", services: []string{"zeta", "alpha", "mid"}, tabs: []string{"zz", "aa"}},
+		{file: "testdata/order/mixed_services.toml", services: []string{"web", "zeta", "api", "beta", "alpha"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.file, func(t *testing.T) {
+			config, err := ParseConfig(tc.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := config.ServiceNames(); !reflect.DeepEqual(got, tc.services) {
+				t.Errorf("services are %v, want %v", got, tc.services)
+			}
+
+			var tabs []string
+			for _, tab := range config.Layout.Tabs {
+				tabs = append(tabs, tab.Name)
+			}
+			if !reflect.DeepEqual(tabs, tc.tabs) {
+				t.Errorf("tabs are %v, want %v", tabs, tc.tabs)
+			}
+		})
+	}
+}
+
+// TestServiceInReservedSection checks a service written as one of runtime's own
+// sections is pointed at [services], while [env] can still define a path variable
+func TestServiceInReservedSection(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		err      string
+	}{
+		{
+			name:     "proxy",
+			document: "[proxy]\npath = \"/\"\nrunCommand = \"caddy run\"\n",
+			err:      "'proxy' is a reserved section; move the service under [services], as [services.proxy]",
+		},
+		{
+			name:     "path only",
+			document: "[layout]\npath = \"/\"\n",
+			err:      "'layout' is a reserved section",
+		},
+		{
+			name:     "env with runCommand",
+			document: "[env]\npath = \"/\"\nrunCommand = \"x\"\n",
+			err:      "'env' is a reserved section",
+		},
+		{
+			name:     "env with a path variable",
+			document: "[env]\npath = \"/usr/bin\"\n\n[services.proxy]\npath = \"/\"\nrunCommand = \"x\"\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeDocument(t, tc.document)
+			_, err := ParseConfig(path)
+			problems := Validate(path, func(string) error { return nil })
+
+			if tc.err == "" {
+				if err != nil || len(problems) > 0 {
+					t.Fatalf("got %v and %d problem(s) from validate, want none", err, len(problems))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("got %v, want an error containing %q", err, tc.err)
+			}
+
+			// runtime validate reports it once, rather than as unknown keys
+			if len(problems) != 1 || !strings.Contains(problems[0].Error(), tc.err) {
+				t.Errorf("validate found:\n%s\nwant only %q", describeProblems(problems), tc.err)
+			}
+		})
+	}
+}
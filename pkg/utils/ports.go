@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"sort"
+
+	"github.com/pelletier/go-toml"
+)
+
+// AutoPort is the value of port that asks runtime to pick a free port
+const AutoPort = "auto"
+
+// DeployedAutoPort is the PORT a port = "auto" service gets when deployed, since each
+// service has its own instance and can't clash with another one there
+const DeployedAutoPort = 8080
+
+// parsePorts reads the optional port and ports keys:
+//
+//	port = 3000       // exported to the service as PORT
+//	port = "auto"     // runtime dev picks a free port and exports it as PORT
+//	ports = [9229]    // more ports the service listens on
+func (p *configParser) parsePorts(svc *toml.Tree, prefix string, service *Service) error {
+	keyPath := joinKey(prefix, "port")
+
+	switch value := getValue(svc, "port").(type) {
+	case nil:
+	case int64:
+		if !validPort(value) {
+			return newKeyError(p.filename, getPosition(svc, "port"), keyPath, "must be between 1 and 65535, got %d", value)
+		}
+		service.Port = int(value)
+		service.Ports = append(service.Ports, int(value))
+	case string:
+		if value != AutoPort {
+			return newKeyError(p.filename, getPosition(svc, "port"), keyPath, "must be a port number or \"auto\", got '%s'", value)
+		}
+		service.AutoPort = true
+	default:
+		return newKeyError(p.filename, getPosition(svc, "port"), keyPath,
+			"must be a port number or \"auto\", got %s", describeType(value))
+	}
+
+	value := getValue(svc, "ports")
+	if value == nil {
+		return nil
+	}
+
+	keyPath = joinKey(prefix, "ports")
+	items, ok := value.([]interface{})
+	if !ok {
+		return newKeyError(p.filename, getPosition(svc, "ports"), keyPath, "must be an array of port numbers, got %s", describeType(value))
+	}
+	for _, item := range items {
+		port, ok := item.(int64)
+		if !ok {
+			return newKeyError(p.filename, getPosition(svc, "ports"), keyPath, "must contain port numbers, got %s", describeType(item))
+		}
+		if !validPort(port) {
+			return newKeyError(p.filename, getPosition(svc, "ports"), keyPath, "must contain ports between 1 and 65535, got %d", port)
+		}
+		service.Ports = append(service.Ports, int(port))
+	}
+
+	return nil
+}
+
+func validPort(port int64) bool {
+	return port >= 1 && port <= 65535
+}
+
+// checkPorts reports ports declared twice, since the second service to bind one would fail
+func (p *configParser) checkPorts(entries []serviceEntry, services []Service) {
+	owners := map[int]string{}
+
+	for i, service := range services {
+		for _, port := range service.Ports {
+			if owner, taken := owners[port]; taken {
+				key := "ports"
+				if port == service.Port {
+					key = "port"
+				}
+				p.report(newKeyError(p.filename, getPosition(entries[i].tree, key), joinKey(entries[i].keyPath, key),
+					"declares port %d, which is already used by '%s'", port, owner))
+				continue
+			}
+			owners[port] = service.Name
+		}
+	}
+}
+
+// DeclaredPorts returns every port the config's services listen on once deployed,
+// sorted and without duplicates
+func (c Config) DeclaredPorts() []int {
+	seen := map[int]bool{}
+	for _, service := range c.Services {
+		for _, port := range service.Ports {
+			seen[port] = true
+		}
+		if service.AutoPort {
+			seen[DeployedAutoPort] = true
+		}
+	}
+
+	ports := make([]int, 0, len(seen))
+	for port := range seen {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
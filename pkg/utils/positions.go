@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// loadTree loads runtime.toml, filling in the key positions go-toml leaves out
+func loadTree(filename string) (*toml.Tree, error) {
+	source, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := toml.LoadBytes(source)
+	if err != nil {
+		return nil, err
+	}
+
+	placeInlineKeys(tree, string(source))
+	return tree, nil
+}
+
+// placeInlineKeys gives keys written inside inline tables, like
+// healthCheck = { tcp = 3000 }, the positions go-toml doesn't record, by finding
+// them in the source. Without them orderedKeys can't keep their order and errors
+// can't point at them.
+func placeInlineKeys(tree *toml.Tree, source string) {
+	s := &keyScanner{tree: tree, input: []rune(source), line: 1, col: 1}
+	s.document()
+}
+
+// keyScanner walks a TOML document that go-toml has already accepted, so it only
+// needs to find keys, not to check the syntax
+type keyScanner struct {
+	tree  *toml.Tree
+	input []rune
+	pos   int
+	line  int
+	col   int
+
+	table   []string // path of the current [table]
+	inArray bool     // the current table is an [[array]] element, which paths can't address
+}
+
+func (s *keyScanner) peek() rune {
+	if s.pos >= len(s.input) {
+		return 0
+	}
+	return s.input[s.pos]
+}
+
+func (s *keyScanner) hasPrefix(prefix string) bool {
+	end := min(s.pos+len(prefix), len(s.input))
+	return string(s.input[s.pos:end]) == prefix
+}
+
+func (s *keyScanner) next() {
+	if s.pos >= len(s.input) {
+		return
+	}
+	if s.input[s.pos] == '\n' {
+		s.line, s.col = s.line+1, 1
+	} else {
+		s.col++
+	}
+	s.pos++
+}
+
+// skip moves past spaces and comments, and past newlines too if multiline is set
+func (s *keyScanner) skip(multiline bool) {
+	for {
+		switch r := s.peek(); {
+		case r == ' ' || r == '\t' || r == '\r' || (multiline && r == '\n'):
+			s.next()
+		case r == '#':
+			for s.peek() != '\n' && s.peek() != 0 {
+				s.next()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (s *keyScanner) document() {
+	for {
+		s.skip(true)
+		switch s.peek() {
+		case 0:
+			return
+		case '[':
+			s.header()
+			continue
+		}
+
+		start := s.pos
+		key, position := s.key()
+		path := append(append([]string{}, s.table...), key...)
+		s.place(path, position)
+		s.skip(false)
+		if s.peek() == '=' {
+			s.next()
+			s.value(path)
+		}
+		if s.pos == start {
+			s.next() // not a key; never the case in a valid document
+		}
+	}
+}
+
+// header reads a [table] or [[array]] header
+func (s *keyScanner) header() {
+	s.next()
+	s.inArray = s.peek() == '['
+	if s.inArray {
+		s.next()
+	}
+	s.table, _ = s.key()
+	for s.peek() != '\n' && s.peek() != 0 {
+		s.next()
+	}
+}
+
+// key reads a possibly dotted key and returns its parts and where it starts
+func (s *keyScanner) key() ([]string, toml.Position) {
+	s.skip(false)
+	position := toml.Position{Line: s.line, Col: s.col}
+
+	var parts []string
+	for {
+		s.skip(false)
+		switch r := s.peek(); {
+		case r == '"' || r == '\'':
+			parts = append(parts, s.quoted(r))
+		case isBareKeyChar(r):
+			start := s.pos
+			for isBareKeyChar(s.peek()) {
+				s.next()
+			}
+			parts = append(parts, string(s.input[start:s.pos]))
+		default:
+			return parts, position
+		}
+
+		s.skip(false)
+		if s.peek() != '.' {
+			return parts, position
+		}
+		s.next()
+	}
+}
+
+func isBareKeyChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-'
+}
+
+// quoted reads a single-line string and returns its value. Escapes are only undone
+// as far as keeping the escaped character, which is enough for keys.
+func (s *keyScanner) quoted(quote rune) string {
+	s.next()
+	var builder strings.Builder
+	for r := s.peek(); r != quote && r != '\n' && r != 0; r = s.peek() {
+		if r == '\\' && quote == '"' {
+			s.next()
+			r = s.peek()
+		}
+		builder.WriteRune(r)
+		s.next()
+	}
+	s.next()
+	return builder.String()
+}
+
+// value skips a value, placing the keys of inline tables in it. path is the key the
+// value belongs to, or nil inside arrays, whose elements paths can't address.
+func (s *keyScanner) value(path []string) {
+	s.skip(false)
+	switch r := s.peek(); {
+	case s.hasPrefix(`"""`) || s.hasPrefix(`'''`):
+		s.multilineString(r)
+	case r == '"' || r == '\'':
+		s.quoted(r)
+	case r == '[':
+		s.next()
+		for {
+			s.skip(true)
+			switch s.peek() {
+			case ']', 0:
+				s.next()
+				return
+			case ',':
+				s.next()
+			default:
+				s.value(nil)
+			}
+		}
+	case r == '{':
+		s.inlineTable(path)
+	default:
+		for s.peek() != 0 && !strings.ContainsRune(" \t\r\n,]}#", s.peek()) {
+			s.next()
+		}
+	}
+}
+
+func (s *keyScanner) multilineString(quote rune) {
+	delimiter := strings.Repeat(string(quote), 3)
+	for i := 0; i < 3; i++ {
+		s.next()
+	}
+
+	for s.peek() != 0 && !s.hasPrefix(delimiter) {
+		if s.peek() == '\\' && quote == '"' {
+			s.next()
+		}
+		s.next()
+	}
+
+	// Up to two quotes right before the delimiter belong to the string
+	for i := 0; i < 5 && s.peek() == quote; i++ {
+		s.next()
+	}
+}
+
+func (s *keyScanner) inlineTable(path []string) {
+	s.next()
+	for {
+		s.skip(true)
+		switch s.peek() {
+		case '}', 0:
+			s.next()
+			return
+		case ',':
+			s.next()
+			continue
+		}
+
+		start := s.pos
+		key, position := s.key()
+		var keyPath []string
+		if path != nil {
+			keyPath = append(append([]string{}, path...), key...)
+			s.place(keyPath, position)
+		}
+		s.skip(false)
+		if s.peek() == '=' {
+			s.next()
+			s.value(keyPath)
+		}
+		if s.pos == start {
+			s.next()
+		}
+	}
+}
+
+// place records where a key starts if go-toml has no position for it, as for keys
+// in inline tables and keys whose value is one
+func (s *keyScanner) place(path []string, position toml.Position) {
+	if !s.inArray && s.tree.GetPositionPath(path).Col == 0 {
+		s.tree.SetPositionPath(path, position)
+	}
+}
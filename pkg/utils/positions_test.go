@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/pelletier/go-toml"
+)
+
+func TestPlaceInlineKeys(t *testing.T) {
+	source := `name = "x" # { not = "a table" }
+note = """
+a = { b = 1 }
+"""
+list = [{ skipped = 1 }, "}"]
+
+[api]
+"quoted key" = 'say "hi" = {'
+health.check = { tcp = 3000, "odd key" = { deep = true } }
+`
+	tree, err := toml.Load(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placeInlineKeys(tree, source)
+
+	for _, tc := range []struct {
+		path []string
+		want toml.Position
+	}{
+		{[]string{"api", "health", "check"}, toml.Position{Line: 9, Col: 1}},
+		{[]string{"api", "health", "check", "tcp"}, toml.Position{Line: 9, Col: 18}},
+		{[]string{"api", "health", "check", "odd key"}, toml.Position{Line: 9, Col: 30}},
+		{[]string{"api", "health", "check", "odd key", "deep"}, toml.Position{Line: 9, Col: 44}},
+	} {
+		if got := tree.GetPositionPath(tc.path); got != tc.want {
+			t.Errorf("%v is at %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// Profile is a named subset of services, e.g. [profiles.frontend-only]
+type Profile struct {
+	Name     string
+	Services []string
+}
+
+// Select returns a copy of the config limited to the services named directly or
+// through a profile, plus everything they depend on. File order is preserved.
+// With no profile and no names the config is returned unchanged.
+func (c Config) Select(profile string, names []string) (Config, error) {
+	if profile == "" && len(names) == 0 {
+		return c, nil
+	}
+
+	requested := append([]string{}, names...)
+	if profile != "" {
+		selected, ok := c.Profiles[profile]
+		if !ok {
+			return Config{}, fmt.Errorf("profile '%s' not found (available: %s)", profile, strings.Join(c.profileNames(), ", "))
+		}
+		requested = append(requested, selected.Services...)
+	}
+
+	// Walk dependencies so a selected service never starts without what it needs
+	included := map[string]bool{}
+	var include func(name string) error
+	include = func(name string) error {
+		if included[name] {
+			return nil
+		}
+		service, ok := c.Service(name)
+		if !ok {
+			return fmt.Errorf("service '%s' not found in runtime.toml", name)
+		}
+
+		included[name] = true
+		for _, dep := range service.DependsOn {
+			if err := include(dep); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, name := range requested {
+		if err := include(name); err != nil {
+			return Config{}, err
+		}
+	}
+
+	selected := c
+	selected.Services = nil
+	for _, service := range c.Services {
+		if included[service.Name] {
+			selected.Services = append(selected.Services, service)
+		}
+	}
+
+	return selected, nil
+}
+
+// ServiceNames returns the names of the config's services in order
+func (c Config) ServiceNames() []string {
+	names := make([]string, len(c.Services))
+	for i, service := range c.Services {
+		names[i] = service.Name
+	}
+	return names
+}
+
+func (c Config) profileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseProfiles reads the [profiles] section and checks every listed service exists
+func (p *configParser) parseProfiles(tree *toml.Tree, services []Service) (map[string]Profile, error) {
+	value := getValue(tree, "profiles")
+	if value == nil {
+		return nil, nil
+	}
+
+	table, ok := value.(*toml.Tree)
+	if !ok {
+		return nil, newKeyError(p.filename, getPosition(tree, "profiles"), "profiles",
+			"must be a table, got %s", describeType(value))
+	}
+
+	known := map[string]bool{}
+	for _, service := range services {
+		known[service.Name] = true
+	}
+
+	profiles := map[string]Profile{}
+	for _, name := range orderedKeys(table) {
+		keyPath := joinKey("profiles", name)
+		profileTree, ok := getValue(table, name).(*toml.Tree)
+		if !ok {
+			return nil, newKeyError(p.filename, getPosition(table, name), keyPath,
+				"must be a table, got %s", describeType(getValue(table, name)))
+		}
+
+		serviceNames, err := p.getStringSlice(profileTree, keyPath, "services")
+		if err != nil {
+			return nil, err
+		}
+		if len(serviceNames) == 0 {
+			return nil, newKeyError(p.filename, profileTree.Position(), keyPath,
+				"must list at least one service in 'services'")
+		}
+
+		for _, serviceName := range serviceNames {
+			if !known[serviceName] {
+				return nil, newKeyError(p.filename, getPosition(profileTree, "services"), joinKey(keyPath, "services"),
+					"references unknown service '%s'", serviceName)
+			}
+		}
+
+		profiles[name] = Profile{Name: name, Services: serviceNames}
+	}
+
+	return profiles, nil
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	config, err := parseDocument(t, `
+[services.db]
+path = "/"
+runCommand = "x"
+
+[services.cache]
+path = "/"
+runCommand = "x"
+
+[services.api]
+path = "/"
+runCommand = "x"
+dependsOn = ["db"]
+
+[services.worker]
+path = "/"
+runCommand = "x"
+dependsOn = ["api", "cache"]
+
+[services.web]
+path = "/"
+runCommand = "x"
+dependsOn = ["api"]
+
+[services.docs]
+path = "/"
+runCommand = "x"
+
+[profiles.frontend]
+services = ["web"]
+
+[profiles.jobs]
+services = ["worker"]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		profile string
+		args    []string
+		want    []string
+		err     string
+	}{
+		{name: "everything", want: []string{"db", "cache", "api", "worker", "web", "docs"}},
+		{name: "service without dependencies", args: []string{"docs"}, want: []string{"docs"}},
+		{name: "service with dependencies", args: []string{"web"}, want: []string{"db", "api", "web"}},
+		{name: "dependencies of dependencies", args: []string{"worker"}, want: []string{"db", "cache", "api", "worker"}},
+		{name: "file order, not argument order", args: []string{"web", "db", "web"}, want: []string{"db", "api", "web"}},
+		{name: "profile", profile: "frontend", want: []string{"db", "api", "web"}},
+		{name: "profile and services", profile: "jobs", args: []string{"docs"}, want: []string{"db", "cache", "api", "worker", "docs"}},
+		{name: "unknown profile", profile: "nope", err: "profile 'nope' not found (available: frontend, jobs)"},
+		{name: "unknown service", args: []string{"web", "nope"}, err: "service 'nope' not found in runtime.toml"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			selected, err := config.Select(tc.profile, tc.args)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got %v, want an error containing %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := selected.ServiceNames(); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	if len(config.Services) != 6 {
+		t.Errorf("Select changed the original config, which now has %d services", len(config.Services))
+	}
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// DefaultProxyPort is where runtime dev's reverse proxy listens unless [proxy] sets port
+const DefaultProxyPort = 4000
+
+// Proxy configures the reverse proxy runtime dev starts when runtime.toml has a
+// [proxy] section. Every service with a port is reachable at
+// <service>.<project>.localhost; Routes and Default share one origin between services.
+type Proxy struct {
+	Port    int
+	Default string       // service that gets requests no route matches; empty for none
+	Routes  []ProxyRoute // longest prefix first
+}
+
+// ProxyRoute sends requests whose path starts with Prefix to a service, e.g. "/api" = "backend"
+type ProxyRoute struct {
+	Prefix  string
+	Service string
+}
+
+// parseProxy reads the optional [proxy] section:
+//
+//	[proxy]
+//	port = 4000
+//	default = "frontend"
+//
+//	[proxy.routes]
+//	"/api" = "backend"
+func (p *configParser) parseProxy(tree *toml.Tree, services []Service) (*Proxy, error) {
+	value := getValue(tree, "proxy")
+	if value == nil {
+		return nil, nil
+	}
+
+	table, ok := value.(*toml.Tree)
+	if !ok {
+		return nil, newKeyError(p.filename, getPosition(tree, "proxy"), "proxy",
+			"must be a table, got %s", describeType(value))
+	}
+
+	proxy := &Proxy{}
+
+	var err error
+	if proxy.Port, err = p.getInt(table, "proxy", "port", DefaultProxyPort); err != nil {
+		return nil, err
+	}
+	if !validPort(int64(proxy.Port)) {
+		return nil, newKeyError(p.filename, getPosition(table, "port"), "proxy.port",
+			"must be between 1 and 65535, got %d", proxy.Port)
+	}
+	for _, service := range services {
+		for _, port := range service.Ports {
+			if port == proxy.Port {
+				return nil, newKeyError(p.filename, getPosition(table, "port"), "proxy.port",
+					"is %d, which is already used by '%s'", port, service.Name)
+			}
+		}
+	}
+
+	if proxy.Default, _, err = p.getString(table, "proxy", "default"); err != nil {
+		return nil, err
+	}
+	if proxy.Default != "" {
+		if err := p.checkProxyTarget(services, proxy.Default, getPosition(table, "default"), "proxy.default"); err != nil {
+			return nil, err
+		}
+	}
+
+	routes, err := p.getStringMap(table, "proxy", "routes")
+	if err != nil {
+		return nil, err
+	}
+	routesTable, _ := getValue(table, "routes").(*toml.Tree)
+
+	for prefix, service := range routes {
+		keyPath := joinKey("proxy.routes", prefix)
+		if !strings.HasPrefix(prefix, "/") {
+			return nil, newKeyError(p.filename, getPosition(routesTable, prefix), keyPath,
+				"must be a path starting with '/'")
+		}
+		if err := p.checkProxyTarget(services, service, getPosition(routesTable, prefix), keyPath); err != nil {
+			return nil, err
+		}
+
+		proxy.Routes = append(proxy.Routes, ProxyRoute{Prefix: strings.TrimSuffix(prefix, "/"), Service: service})
+	}
+
+	// Match /api/admin before /api
+	sort.Slice(proxy.Routes, func(i, j int) bool {
+		return len(proxy.Routes[i].Prefix) > len(proxy.Routes[j].Prefix)
+	})
+
+	return proxy, nil
+}
+
+// checkProxyTarget verifies the proxy can reach a service, which needs a declared port
+func (p *configParser) checkProxyTarget(services []Service, name string, position toml.Position, keyPath string) error {
+	for _, service := range services {
+		if service.Name != name {
+			continue
+		}
+		if service.Port == 0 && !service.AutoPort {
+			return newKeyError(p.filename, position, keyPath,
+				"references '%s', which has no port; add port = <number> or port = \"auto\" to it", name)
+		}
+		return nil
+	}
+
+	return newKeyError(p.filename, position, keyPath, "references unknown service '%s'", name)
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/pelletier/go-toml"
+)
+
+const (
+	RestartNo        = "no"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+// RestartPolicy describes when a service that exits on its own is started again
+type RestartPolicy struct {
+	Policy     string        // no, on-failure or always
+	MaxRetries int           // restarts in a row before giving up; 0 means no limit
+	Backoff    time.Duration // delay before the first restart, doubled for each one after it
+	MaxBackoff time.Duration // upper bound for the delay
+}
+
+// Defaults used when a restart policy leaves them out
+const (
+	defaultRestartMaxRetries = 5
+	defaultRestartBackoff    = time.Second
+	defaultRestartMaxBackoff = 30 * time.Second
+)
+
+// ShouldRestart reports whether a service that exited with exitCode is restarted
+func (r RestartPolicy) ShouldRestart(exitCode int) bool {
+	switch r.Policy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return exitCode != 0
+	default:
+		return false
+	}
+}
+
+// Delay returns how long to wait before the given restart attempt, counting from 1
+func (r RestartPolicy) Delay(attempt int) time.Duration {
+	delay := r.Backoff
+	for i := 1; i < attempt && delay < r.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.MaxBackoff {
+		delay = r.MaxBackoff
+	}
+	return delay
+}
+
+// parseRestartPolicy reads the optional restart key, either a policy name like
+// restart = "on-failure" or a table like
+// restart = { policy = "on-failure", maxRetries = 10, backoff = "500ms", maxBackoff = "1m" }
+func (p *configParser) parseRestartPolicy(svc *toml.Tree, prefix string) (RestartPolicy, error) {
+	policy := RestartPolicy{
+		Policy:     RestartNo,
+		MaxRetries: defaultRestartMaxRetries,
+		Backoff:    defaultRestartBackoff,
+		MaxBackoff: defaultRestartMaxBackoff,
+	}
+
+	keyPath := joinKey(prefix, "restart")
+	policyPath, position := keyPath, getPosition(svc, "restart")
+
+	switch value := getValue(svc, "restart").(type) {
+	case nil:
+		return policy, nil
+	case string:
+		policy.Policy = value
+	case *toml.Tree:
+		name, ok, err := p.getString(value, keyPath, "policy")
+		if err != nil {
+			return RestartPolicy{}, err
+		}
+		if !ok {
+			return RestartPolicy{}, newKeyError(p.filename, position, keyPath, "is missing required key 'policy'")
+		}
+		policy.Policy = name
+		policyPath, position = joinKey(keyPath, "policy"), getPosition(value, "policy")
+
+		if policy.MaxRetries, err = p.getInt(value, keyPath, "maxRetries", policy.MaxRetries); err != nil {
+			return RestartPolicy{}, err
+		}
+		if policy.Backoff, err = p.getDuration(value, keyPath, "backoff", policy.Backoff); err != nil {
+			return RestartPolicy{}, err
+		}
+		if policy.MaxBackoff, err = p.getDuration(value, keyPath, "maxBackoff", policy.MaxBackoff); err != nil {
+			return RestartPolicy{}, err
+		}
+
+		if policy.Backoff == 0 {
+			return RestartPolicy{}, newKeyError(p.filename, getPosition(value, "backoff"), joinKey(keyPath, "backoff"),
+				"must be greater than zero")
+		}
+		if policy.MaxBackoff < policy.Backoff {
+			return RestartPolicy{}, newKeyError(p.filename, getPosition(value, "maxBackoff"), joinKey(keyPath, "maxBackoff"),
+				"must not be shorter than backoff (%v)", policy.Backoff)
+		}
+	default:
+		return RestartPolicy{}, newKeyError(p.filename, position, keyPath,
+			"must be a string or a table, got %s", describeType(value))
+	}
+
+	if policy.Policy != RestartNo && policy.Policy != RestartOnFailure && policy.Policy != RestartAlways {
+		return RestartPolicy{}, newKeyError(p.filename, position, policyPath,
+			"must be \"no\", \"on-failure\" or \"always\", got '%s'", policy.Policy)
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeDocument writes a runtime.toml with the given contents and returns its path
+func writeDocument(t *testing.T, document string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "runtime.toml")
+	if err := os.WriteFile(path, []byte(document), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// parseDocument parses a runtime.toml with the given contents
+func parseDocument(t *testing.T, document string) (Config, error) {
+	t.Helper()
+	return ParseConfig(writeDocument(t, document))
+}
+
+// TestParseRestartPolicy checks both forms of the restart key, the defaults they
+// fall back to and the values they reject
+func TestParseRestartPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		restart string
+		want    RestartPolicy
+		err     string
+	}{
+		{
+			name: "left out",
+			want: RestartPolicy{Policy: RestartNo, MaxRetries: 5, Backoff: time.Second, MaxBackoff: 30 * time.Second},
+		},
+		{
+			name:    "string",
+			restart: `restart = "on-failure"`,
+			want:    RestartPolicy{Policy: RestartOnFailure, MaxRetries: 5, Backoff: time.Second, MaxBackoff: 30 * time.Second},
+		},
+		{
+			name:    "table with defaults",
+			restart: `restart = { policy = "always" }`,
+			want:    RestartPolicy{Policy: RestartAlways, MaxRetries: 5, Backoff: time.Second, MaxBackoff: 30 * time.Second},
+		},
+		{
+			name:    "table",
+			restart: `restart = { policy = "on-failure", maxRetries = 0, backoff = "500ms", maxBackoff = "1m" }`,
+			want:    RestartPolicy{Policy: RestartOnFailure, MaxRetries: 0, Backoff: 500 * time.Millisecond, MaxBackoff: time.Minute},
+		},
+		{
+			name:    "unknown policy",
+			restart: `restart = "sometimes"`,
+			err:     `'api.restart' must be "no", "on-failure" or "always", got 'sometimes'`,
+		},
+		{
+			name:    "unknown policy in table",
+			restart: `restart = { policy = "sometimes" }`,
+			err:     `'api.restart.policy' must be "no", "on-failure" or "always"`,
+		},
+		{
+			name:    "table without policy",
+			restart: `restart = { maxRetries = 3 }`,
+			err:     "'api.restart' is missing required key 'policy'",
+		},
+		{
+			name:    "zero backoff",
+			restart: `restart = { policy = "always", backoff = "0s" }`,
+			err:     "'api.restart.backoff' must be greater than zero",
+		},
+		{
+			name:    "maxBackoff below backoff",
+			restart: `restart = { policy = "always", backoff = "10s", maxBackoff = "5s" }`,
+			err:     "'api.restart.maxBackoff' must not be shorter than backoff (10s)",
+		},
+		{
+			name:    "wrong type",
+			restart: `restart = true`,
+			err:     "'api.restart' must be a string or a table",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := parseDocument(t, "[api]\npath = \".\"\nrunCommand = \"x\"\n"+tc.restart+"\n")
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got %v, want an error containing %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := config.Services[0].Restart; got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestRestartDelay checks the delay doubles with each attempt and stops at maxBackoff
+func TestRestartDelay(t *testing.T) {
+	policy := RestartPolicy{Policy: RestartAlways, Backoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, delay := range want {
+		if got := policy.Delay(i + 1); got != delay {
+			t.Errorf("attempt %d waits %v, want %v", i+1, got, delay)
+		}
+	}
+}
+
+func TestShouldRestart(t *testing.T) {
+	tests := []struct {
+		policy   string
+		exitCode int
+		want     bool
+	}{
+		{RestartNo, 1, false},
+		{RestartOnFailure, 0, false},
+		{RestartOnFailure, 2, true},
+		{RestartAlways, 0, true},
+	}
+
+	for _, tc := range tests {
+		if got := (RestartPolicy{Policy: tc.policy}).ShouldRestart(tc.exitCode); got != tc.want {
+			t.Errorf("policy %s with exit code %d restarts: %v, want %v", tc.policy, tc.exitCode, got, tc.want)
+		}
+	}
+}
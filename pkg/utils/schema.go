@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// schema describes a value in runtime.toml. It is the one description of the file's
+// structure: runtime schema turns it into a JSON Schema for editors, and runtime
+// validate uses it to find unknown keys, so a key added here reaches both.
+// TestSchemaMatchesParser fails when the parser and the schema disagree on a key.
+type schema struct {
+	ref         string   // name under JSON Schema definitions, for schemas used in several places
+	types       []string // "string", "integer", "number", "boolean", "array" or "object"
+	description string
+	enum        []string
+	pattern     string
+	minimum     *int
+	maximum     *int
+	items       *schema    // for arrays
+	properties  []property // for objects, in documentation order
+	required    []string
+	additional  *schema   // for objects: the schema of keys not in properties; nil allows none
+	anyOf       []*schema // values that can be written in several forms
+}
+
+// property is a key of an object schema
+type property struct {
+	name   string
+	schema *schema
+}
+
+func intPtr(n int) *int { return &n }
+
+func stringSchema(description string) *schema {
+	return &schema{types: []string{"string"}, description: description}
+}
+
+func enumSchema(description string, values ...string) *schema {
+	return &schema{types: []string{"string"}, description: description, enum: values}
+}
+
+func portSchema(description string) *schema {
+	return &schema{types: []string{"integer"}, description: description, minimum: intPtr(1), maximum: intPtr(65535)}
+}
+
+func countSchema(description string) *schema {
+	return &schema{types: []string{"integer"}, description: description, minimum: intPtr(0)}
+}
+
+// durationSchema matches what getDuration accepts: a Go duration string or a number of seconds
+func durationSchema(description string) *schema {
+	return &schema{description: description, anyOf: []*schema{
+		{types: []string{"string"}, pattern: `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`},
+		{types: []string{"integer"}, minimum: intPtr(0)},
+	}}
+}
+
+// stringListSchema matches what getStringSlice accepts: an array of strings or a single string
+func stringListSchema(description string) *schema {
+	return &schema{description: description, anyOf: []*schema{
+		{types: []string{"array"}, items: &schema{types: []string{"string"}}},
+		{types: []string{"string"}},
+	}}
+}
+
+// stringMapSchema matches what getStringMap accepts: a table of scalars
+func stringMapSchema(description string) *schema {
+	return &schema{types: []string{"object"}, description: description,
+		additional: &schema{types: []string{"string", "integer", "number", "boolean"}}}
+}
+
+func tableSchema(description string, properties ...property) *schema {
+	return &schema{types: []string{"object"}, description: description, properties: properties}
+}
+
+// gcpProperties are the GCP settings, accepted both in a gcp table and as keys of the
+// project or a service
+var gcpProperties = []property{
+	{"region", stringSchema("GCP region to create instances in, like \"europe-west1\"; a zone in it is picked unless zone is set")},
+	{"zone", stringSchema("GCP zone to create instances in (default \"us-central1-a\")")},
+	{"image", stringSchema("Boot image for GCP instances: a family like \"debian-12\" (from debian-cloud) or \"ubuntu-os-cloud/ubuntu-2204-lts\", or a full image path (default \"debian-11\")")},
+	{"diskSizeGb", &schema{types: []string{"integer"}, description: "Boot disk size of GCP instances in GB (default 10)", minimum: intPtr(minDiskSizeGb)}},
+	{"diskType", stringSchema("Boot disk type of GCP instances, like \"pd-standard\", \"pd-balanced\" or \"pd-ssd\" (default \"pd-standard\")")},
+}
+
+var gcpSchema = &schema{
+	ref:         "gcp",
+	types:       []string{"object"},
+	description: "Instance settings for services with runsOn = \"gcp.<machineType>\"",
+	properties:  gcpProperties,
+}
+
+var serviceSchema = &schema{
+	ref:         "service",
+	types:       []string{"object"},
+	description: "A service runtime dev runs in its own tab",
+	required:    []string{"path", "runCommand"},
+	properties: append([]property{
+		{"path", stringSchema("Directory the service runs in, relative to runtime.toml")},
+		{"runCommand", stringSchema("Command that starts the service")},
+		{"runsOn", stringSchema("Where runtime deploy runs the service, like \"gcp.e2-standard-4\", \"ssh://deploy@10.0.0.5\", \"ssh.<host>\" or \"docker.local\"")},
+		{"env", stringMapSchema("Environment variables for this service; these win over [env] and envFile")},
+		{"envFile", stringListSchema("dotenv files to load, relative to path; missing files are skipped")},
+		{"dependsOn", stringListSchema("Services that must be started (and healthy, if they have a health check) first")},
+		{"healthCheck", tableSchema("How runtime knows the service is ready; set exactly one of tcp, http or command",
+			property{HealthCheckTCP, &schema{description: "Port or host:port that accepts connections once ready",
+				types: []string{"integer", "string"}}},
+			property{HealthCheckHTTP, &schema{types: []string{"string"}, pattern: "^https?://",
+				description: "URL that returns a 2xx response once ready"}},
+			property{HealthCheckCommand, stringSchema("Command that exits with code 0 once ready")},
+			property{"interval", durationSchema("Time between attempts, like \"2s\" or \"500ms\" (default 2s)")},
+			property{"timeout", durationSchema("Time each attempt may take (default 2s)")},
+			property{"retries", countSchema("Attempts before giving up (default 30)")},
+		)},
+		{"restart", &schema{description: "Whether a service that exits on its own is started again", anyOf: []*schema{
+			enumSchema("", RestartNo, RestartOnFailure, RestartAlways),
+			{
+				types:    []string{"object"},
+				required: []string{"policy"},
+				properties: []property{
+					{"policy", enumSchema("When to restart", RestartNo, RestartOnFailure, RestartAlways)},
+					{"maxRetries", countSchema("Restarts in a row before giving up, 0 for no limit (default 5)")},
+					{"backoff", durationSchema("Delay before the first restart, doubled each time (default 1s)")},
+					{"maxBackoff", durationSchema("Longest delay between restarts (default 30s)")},
+				},
+			},
+		}}},
+		{"watch", stringListSchema("Globs, relative to path, whose changes restart the service")},
+		{"ignore", stringListSchema("Globs excluded from watch")},
+		{"port", &schema{description: "Port the service listens on, exported as PORT; \"auto\" picks a free one", anyOf: []*schema{
+			portSchema(""),
+			enumSchema("", AutoPort),
+		}}},
+		{"ports", &schema{types: []string{"array"}, description: "Other ports the service listens on", items: portSchema("")}},
+		{"gcp", gcpSchema},
+	}, gcpProperties...),
+}
+
+// configSchema describes the whole file. Top-level tables that aren't runtime's own
+// sections are services, like the ones under [services].
+var configSchema = &schema{
+	types:       []string{"object"},
+	description: "runtime.toml, the project file for runtime",
+	properties: append([]property{
+		{"name", stringSchema("Project name, used for the dev session and deployed instance names")},
+		{"multiplexer", enumSchema("Terminal multiplexer runtime dev uses", Multiplexers...)},
+		{"env", stringMapSchema("Environment variables shared by every service")},
+		{"services", &schema{types: []string{"object"}, description: "Services, kept apart from runtime's own settings",
+			additional: serviceSchema}},
+		{"profiles", &schema{types: []string{"object"}, description: "Named subsets of services, for runtime dev --profile",
+			additional: &schema{types: []string{"object"}, required: []string{"services"}, properties: []property{
+				{"services", stringListSchema("Services in the profile; their dependencies are included too")},
+			}}}},
+		{"layout", tableSchema("How runtime dev arranges services into tabs",
+			property{"tabs", &schema{types: []string{"object"}, description: "Tabs holding several panes",
+				additional: &schema{types: []string{"object"}, required: []string{"panes"}, properties: []property{
+					{"panes", stringListSchema("Services and extra panes in the tab")},
+					{"split", enumSchema("vertical puts panes side by side, horizontal stacks them", SplitVertical, SplitHorizontal)},
+					{"sizes", &schema{types: []string{"array"}, description: "Size of each pane, like \"60%\"",
+						items: &schema{types: []string{"string", "integer"}}}},
+				}}}},
+			property{"panes", &schema{types: []string{"object"}, description: "Extra panes that aren't services",
+				additional: tableSchema("",
+					property{"command", stringSchema("Command to run; leave out for an interactive shell")},
+					property{"path", stringSchema("Working directory, relative to runtime.toml")},
+				)}},
+		)},
+		{"proxy", tableSchema("Reverse proxy runtime dev starts in front of services",
+			property{"port", portSchema("Port the proxy listens on (default " + strconv.Itoa(DefaultProxyPort) + ")")},
+			property{"default", stringSchema("Service that gets requests no route matches")},
+			property{"routes", &schema{types: []string{"object"}, description: "Path prefixes, like \"/api\", and the service each goes to",
+				additional: &schema{types: []string{"string"}}}},
+		)},
+		{"gcp", gcpSchema},
+		{"hosts", &schema{types: []string{"object"}, description: "Servers of your own that runtime deploy reaches over SSH, for runsOn = \"ssh.<name>\"",
+			additional: &schema{types: []string{"object"}, required: []string{"address"}, properties: []property{
+				{"address", stringSchema("Hostname or IP address")},
+				{"user", stringSchema("SSH user; leave out to use your ssh config")},
+				{"port", portSchema("SSH port (default 22)")},
+			}}}},
+	}, gcpProperties...),
+	additional: serviceSchema,
+}
+
+// JSONSchema returns a JSON Schema (draft-07) for runtime.toml, for editors like
+// VS Code with Even Better TOML or Taplo
+func JSONSchema() ([]byte, error) {
+	definitions := map[string]interface{}{}
+	root := configSchema.toJSON(definitions)
+	root["$schema"] = "http://json-schema.org/draft-07/schema#"
+	root["title"] = "runtime.toml"
+	root["definitions"] = definitions
+
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// toJSON converts the schema to JSON Schema, moving schemas with a ref into definitions
+func (s *schema) toJSON(definitions map[string]interface{}) map[string]interface{} {
+	if s.ref != "" {
+		if _, done := definitions[s.ref]; !done {
+			definitions[s.ref] = map[string]interface{}{} // placeholder for recursive references
+			inline := *s
+			inline.ref = ""
+			definitions[s.ref] = inline.toJSON(definitions)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + s.ref}
+	}
+
+	out := map[string]interface{}{}
+	switch len(s.types) {
+	case 0:
+	case 1:
+		out["type"] = s.types[0]
+	default:
+		out["type"] = s.types
+	}
+	if s.description != "" {
+		out["description"] = s.description
+	}
+	if len(s.enum) > 0 {
+		out["enum"] = s.enum
+	}
+	if s.pattern != "" {
+		out["pattern"] = s.pattern
+	}
+	if s.minimum != nil {
+		out["minimum"] = *s.minimum
+	}
+	if s.maximum != nil {
+		out["maximum"] = *s.maximum
+	}
+	if s.items != nil {
+		out["items"] = s.items.toJSON(definitions)
+	}
+
+	if s.isObject() {
+		properties := map[string]interface{}{}
+		for _, p := range s.properties {
+			properties[p.name] = p.schema.toJSON(definitions)
+		}
+		if len(properties) > 0 {
+			out["properties"] = properties
+		}
+		if s.additional != nil {
+			out["additionalProperties"] = s.additional.toJSON(definitions)
+		} else {
+			out["additionalProperties"] = false
+		}
+	}
+	if len(s.required) > 0 {
+		out["required"] = s.required
+	}
+
+	if len(s.anyOf) > 0 {
+		var alternatives []interface{}
+		for _, alternative := range s.anyOf {
+			alternatives = append(alternatives, alternative.toJSON(definitions))
+		}
+		out["anyOf"] = alternatives
+	}
+
+	return out
+}
+
+func (s *schema) isObject() bool {
+	return contains(s.types, "object")
+}
+
+// table returns the schema that applies when the value is written as a table
+func (s *schema) table() (*schema, bool) {
+	if s.isObject() {
+		return s, true
+	}
+	for _, alternative := range s.anyOf {
+		if alternative.isObject() {
+			return alternative, true
+		}
+	}
+	return nil, false
+}
+
+// property returns the schema of a key in an object schema
+func (s *schema) property(name string) (*schema, bool) {
+	for _, p := range s.properties {
+		if p.name == name {
+			return p.schema, true
+		}
+	}
+	return nil, false
+}
+
+func (s *schema) propertyNames() []string {
+	names := make([]string, len(s.properties))
+	for i, p := range s.properties {
+		names[i] = p.name
+	}
+	return names
+}
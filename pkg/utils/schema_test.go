@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml"
+)
+
+// TestSchemaMatchesParser keeps configSchema, which runtime validate and runtime
+// schema use, in step with what ParseConfig reads. testdata/every_key.toml sets
+// every key; parsing it must only read keys the schema describes, and every key
+// the schema describes must be read.
+func TestSchemaMatchesParser(t *testing.T) {
+	const filename = "testdata/every_key.toml"
+
+	tree, err := loadTree(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Key paths of every table in the document, to place each read
+	paths := map[*toml.Tree][]string{tree: nil}
+	var collect func(table *toml.Tree, path []string)
+	collect = func(table *toml.Tree, path []string) {
+		for _, key := range table.Keys() {
+			if child, ok := table.GetPath([]string{key}).(*toml.Tree); ok {
+				childPath := append(append([]string{}, path...), key)
+				paths[child] = childPath
+				collect(child, childPath)
+			}
+		}
+	}
+	collect(tree, nil)
+
+	used := map[*schema]map[string]bool{}
+	keyRead = func(table *toml.Tree, key string) {
+		path, ok := paths[table]
+		if !ok {
+			t.Errorf("parser read '%s' from a table outside the document", key)
+			return
+		}
+		if err := markRead(configSchema, append(append([]string{}, path...), key), used); err != nil {
+			t.Errorf("parser reads '%s', which the schema doesn't describe: %v", joinPath(path, key), err)
+		}
+	}
+	defer func() { keyRead = nil }()
+
+	if _, err := parseTree(filename, tree); err != nil {
+		t.Fatalf("%s doesn't parse: %v", filename, err)
+	}
+
+	for _, missing := range unreadProperties(configSchema, "", used, map[*schema]bool{}) {
+		t.Errorf("schema describes '%s', but the parser never reads it (or testdata/every_key.toml doesn't set it)", missing)
+	}
+}
+
+// TestSchemaAcceptsEveryKey checks runtime validate finds nothing wrong with a file
+// using every key, so the schema accepts everything the parser does
+func TestSchemaAcceptsEveryKey(t *testing.T) {
+	problems := Validate("testdata/every_key.toml", func(string) error { return nil })
+	for _, problem := range problems {
+		t.Errorf("unexpected problem: %v", problem)
+	}
+}
+
+func TestJSONSchemaIsValidJSON(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if _, ok := decoded["definitions"].(map[string]interface{})["service"]; !ok {
+		t.Error("schema has no service definition")
+	}
+}
+
+// markRead follows a key path through the schema, recording the properties it
+// passes, and fails when the schema has no place for the path
+func markRead(s *schema, path []string, used map[*schema]map[string]bool) error {
+	for _, key := range path {
+		table, ok := s.table()
+		if !ok {
+			return nil // scalar values like "auto" have no keys below them
+		}
+
+		if property, ok := table.property(key); ok {
+			if used[table] == nil {
+				used[table] = map[string]bool{}
+			}
+			used[table][key] = true
+			s = property
+			continue
+		}
+		if table.additional == nil {
+			return &keyPathError{key: key, known: table.propertyNames()}
+		}
+		s = table.additional
+	}
+	return nil
+}
+
+// unreadProperties lists the schema's properties that markRead never passed
+func unreadProperties(s *schema, prefix string, used map[*schema]map[string]bool, visited map[*schema]bool) []string {
+	if s == nil || visited[s] {
+		return nil
+	}
+	visited[s] = true
+
+	var unread []string
+	if table, ok := s.table(); ok {
+		for _, p := range table.properties {
+			if !used[table][p.name] {
+				unread = append(unread, joinKey(prefix, p.name))
+			}
+			unread = append(unread, unreadProperties(p.schema, joinKey(prefix, p.name), used, visited)...)
+		}
+		unread = append(unread, unreadProperties(table.additional, joinKey(prefix, "<name>"), used, visited)...)
+	}
+	return append(unread, unreadProperties(s.items, prefix, used, visited)...)
+}
+
+type keyPathError struct {
+	key   string
+	known []string
+}
+
+func (e *keyPathError) Error() string {
+	return "'" + e.key + "' is not one of: " + strings.Join(e.known, ", ")
+}
+
+func joinPath(path []string, key string) string {
+	return strings.Join(append(append([]string{}, path...), key), ".")
+}
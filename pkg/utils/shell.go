@@ -0,0 +1,8 @@
+package utils
+
+import "strings"
+
+// ShellQuote wraps a value in single quotes so a POSIX shell treats it as one literal word
+func ShellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// Validate checks runtime.toml more thoroughly than ParseConfig: besides everything
+// ParseConfig rejects, it reports unknown keys, service paths missing on disk and
+// runsOn values checkRunsOn rejects. Problems are returned in file order; none means
+// the file is valid.
+func Validate(filename string, checkRunsOn func(runsOn string) error) []*ConfigError {
+	// ParseConfig reports missing files and syntax errors with their position
+	tree, err := loadTree(filename)
+	if err != nil {
+		_, err := ParseConfig(filename)
+		return []*ConfigError{asConfigError(filename, err)}
+	}
+
+	p := &configParser{filename: filename}
+	configDir, _ := filepath.Abs(filepath.Dir(filename))
+
+	problems := p.checkKeys(tree, configSchema, "", true)
+	problems = append(problems, p.checkServices(tree, configDir, checkRunsOn)...)
+
+	// Add what the parser finds, leaving out problems already reported. A misspelt
+	// section, like [porfiles], is read as a service missing its keys; that follows
+	// from the typo, so it isn't reported again.
+	p.parse(tree)
+	for _, problem := range p.problems {
+		if !isReported(problems, problem) && !inMisspeltSection(tree, problem.Key) {
+			problems = append(problems, problem)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Column < problems[j].Column
+	})
+	return problems
+}
+
+// isReported says whether a problem with the same position and message is in problems
+func isReported(problems []*ConfigError, problem *ConfigError) bool {
+	for _, reported := range problems {
+		if reported.Line == problem.Line && reported.Column == problem.Column && reported.Message == problem.Message {
+			return true
+		}
+	}
+	return false
+}
+
+func asConfigError(filename string, err error) *ConfigError {
+	var configErr *ConfigError
+	if errors.As(err, &configErr) {
+		return configErr
+	}
+	return &ConfigError{File: filename, Message: err.Error(), Err: err}
+}
+
+// checkKeys reports keys the schema doesn't accept, recursing into nested tables.
+// At the top level, tables that aren't runtime's own sections are services.
+func (p *configParser) checkKeys(tree *toml.Tree, spec *schema, prefix string, topLevel bool) []*ConfigError {
+	var problems []*ConfigError
+
+	for _, key := range orderedKeys(tree) {
+		keyPath := joinKey(prefix, key)
+		value := getValue(tree, key)
+		child, isTable := value.(*toml.Tree)
+
+		valueSchema, known := spec.property(key)
+		if !known && spec.additional != nil && (isTable || !spec.additional.isObject()) {
+			valueSchema, known = spec.additional, true
+			if topLevel {
+				problems = append(problems, p.checkServiceTable(child, key)...)
+				continue
+			}
+		}
+		if !known {
+			problems = append(problems, p.unknownKey(getPosition(tree, key), keyPath, key, spec.propertyNames()))
+			continue
+		}
+
+		// A service written as [proxy] would otherwise show as a pile of unknown keys
+		if topLevel && isTable && misplacedService(key, child) {
+			problems = append(problems, p.misplacedServiceError(tree, key))
+			continue
+		}
+
+		if table, ok := valueSchema.table(); ok && isTable {
+			problems = append(problems, p.checkKeys(child, table, keyPath, false)...)
+		}
+	}
+
+	return problems
+}
+
+// checkServiceTable checks a top-level table as a service, or as the section its name
+// is a typo of
+func (p *configParser) checkServiceTable(tree *toml.Tree, name string) []*ConfigError {
+	if suggestion, ok := misspeltSection(tree, name); ok {
+		return []*ConfigError{newKeyError(p.filename, tree.Position(), name,
+			"is not a known section, did you mean '%s'?", suggestion)}
+	}
+	return p.checkKeys(tree, serviceSchema, name, false)
+}
+
+// misspeltSection returns the section a top-level table is most likely a typo of: one
+// that has neither path nor runCommand but is named like one of runtime's sections
+func misspeltSection(tree *toml.Tree, name string) (string, bool) {
+	if tree.Has("path") || tree.Has("runCommand") {
+		return "", false
+	}
+	return ClosestMatch(name, sectionNames())
+}
+
+// inMisspeltSection says whether a key path is inside a misspelt section, or inside
+// one of runtime's sections that holds a service instead
+func inMisspeltSection(tree *toml.Tree, keyPath string) bool {
+	name, rest, _ := strings.Cut(keyPath, ".")
+	table, ok := getValue(tree, name).(*toml.Tree)
+	if !ok {
+		return false
+	}
+	if reservedSections[name] {
+		return rest != "" && misplacedService(name, table)
+	}
+	_, misspelt := misspeltSection(table, name)
+	return misspelt
+}
+
+// unknownKey reports a key the table doesn't accept, suggesting the closest one it does
+func (p *configParser) unknownKey(position toml.Position, keyPath, key string, known []string) *ConfigError {
+	if suggestion, ok := ClosestMatch(key, known); ok {
+		return newKeyError(p.filename, position, keyPath, "is not a known key, did you mean '%s'?", suggestion)
+	}
+	return newKeyError(p.filename, position, keyPath, "is not a known key (expected one of: %s)", strings.Join(known, ", "))
+}
+
+// checkServices reports problems ParseConfig doesn't look for: paths that don't
+// exist and runsOn values runtime deploy can't handle
+func (p *configParser) checkServices(tree *toml.Tree, configDir string, checkRunsOn func(string) error) []*ConfigError {
+	entries, _ := p.serviceEntries(tree)
+
+	var problems []*ConfigError
+	for _, entry := range entries {
+		if path, ok := getValue(entry.tree, "path").(string); ok {
+			if info, err := os.Stat(filepath.Join(configDir, path)); err != nil || !info.IsDir() {
+				problems = append(problems, newKeyError(p.filename, getPosition(entry.tree, "path"), joinKey(entry.keyPath, "path"),
+					"points to '%s', which is not a directory", path))
+			}
+		}
+
+		if runsOn, ok := getValue(entry.tree, "runsOn").(string); ok {
+			if err := checkRunsOn(runsOn); err != nil {
+				problems = append(problems, newKeyError(p.filename, getPosition(entry.tree, "runsOn"), joinKey(entry.keyPath, "runsOn"),
+					"is invalid: %v", err))
+			}
+		}
+	}
+	return problems
+}
+
+func sectionNames() []string {
+	names := make([]string, 0, len(reservedSections))
+	for name := range reservedSections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ClosestMatch returns the candidate that a mistyped name most likely meant
+func ClosestMatch(name string, candidates []string) (string, bool) {
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if bestDistance == -1 || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	// Allow about one typo per four characters, so short keys need a close match
+	limit := len(name) / 4
+	if limit < 1 {
+		limit = 1
+	}
+	if bestDistance == -1 || bestDistance > limit {
+		return "", false
+	}
+	return best, true
+}
+
+// editDistance counts the insertions, deletions, substitutions and swaps of
+// neighbouring characters needed to turn a into b
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidateReportsEveryProblem checks one problem doesn't hide the others, and
+// that different problems with the same key are all reported
+func TestValidateReportsEveryProblem(t *testing.T) {
+	problems := Validate("testdata/many_problems.toml", func(string) error { return nil })
+
+	want := []string{
+		"runtime.toml:8:29: 'api.healthCheck.healthchek' is not a known key",
+		"runtime.toml:13:1: 'web.port' declares port 3000, which is already used by 'api'",
+		"runtime.toml:14:1: 'web.dependsOn' references unknown service 'nope'",
+		"runtime.toml:14:1: 'web.dependsOn' creates a dependency cycle: api -> web -> api",
+		"runtime.toml:16:1: 'porfiles' is not a known section, did you mean 'profiles'?",
+	}
+	if len(problems) != len(want) {
+		t.Errorf("got %d problems, want %d:\n%s", len(problems), len(want), describeProblems(problems))
+	}
+	for i, problem := range problems {
+		got := strings.Replace(problem.Error(), "testdata/many_problems.toml", "runtime.toml", 1)
+		if i < len(want) && !strings.HasPrefix(got, want[i]) {
+			t.Errorf("problem %d is %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+// TestParseConfigStopsAtFirstProblem checks ParseConfig still fails with the first
+// problem in the order it reads the file
+func TestParseConfigStopsAtFirstProblem(t *testing.T) {
+	_, err := ParseConfig("testdata/many_problems.toml")
+	if err == nil || !strings.Contains(err.Error(), "'porfiles' is missing required key 'path'") {
+		t.Errorf("got %v, want the missing path of the misspelt [porfiles]", err)
+	}
+}
+
+func describeProblems(problems []*ConfigError) string {
+	lines := make([]string, len(problems))
+	for i, problem := range problems {
+		lines[i] = problem.Error()
+	}
+	return strings.Join(lines, "\n")
+}
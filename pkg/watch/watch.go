@@ -0,0 +1,184 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/The-Pirateship/runtime/pkg/utils"
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long to wait for changes to settle before reporting them,
+// so saving many files at once (a git checkout, a formatter) causes a single restart
+const DefaultDebounce = 300 * time.Millisecond
+
+// skippedDirs are never watched: they are large, or change whenever runtime itself runs
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	".runtime":     true,
+	".zellij":      true,
+}
+
+// Watcher reports changes to files under Root that match Patterns and not Ignore.
+// Patterns are relative to Root and use utils.MatchGlob syntax.
+type Watcher struct {
+	Root     string
+	Patterns []string
+	Ignore   []string
+	Debounce time.Duration
+
+	// OnError, if set, is told about errors the watch carries on after, such as
+	// events dropped because too many arrived at once
+	OnError func(err error)
+}
+
+// Run watches until ctx is cancelled, calling onChange with the relative paths that
+// changed once no more changes have arrived for the debounce period
+func (w *Watcher) Run(ctx context.Context, onChange func(changed []string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// fsnotify isn't recursive, so every directory is watched on its own
+	if err := w.addTree(watcher, w.Root); err != nil {
+		return err
+	}
+
+	debounce := w.Debounce
+	if debounce == 0 {
+		debounce = DefaultDebounce
+	}
+
+	pending := map[string]bool{}
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			// New directories need watching too, along with anything created in them already
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if !w.ignoredDir(event.Name) {
+						w.addTree(watcher, event.Name)
+					}
+					continue
+				}
+			}
+
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+
+			rel, ok := w.relative(event.Name)
+			if !ok || !w.matches(rel) {
+				continue
+			}
+
+			pending[rel] = true
+			timer.Reset(debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			// At worst some changes were missed, so keep watching for the next ones
+			if w.OnError != nil {
+				w.OnError(err)
+			}
+
+		case <-timer.C:
+			changed := make([]string, 0, len(pending))
+			for rel := range pending {
+				changed = append(changed, rel)
+			}
+			sort.Strings(changed)
+			pending = map[string]bool{}
+
+			onChange(changed)
+		}
+	}
+}
+
+// addTree watches dir and every directory below it that isn't skipped or ignored
+func (w *Watcher) addTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Directories can vanish while we walk; only the root has to exist
+			if path == dir {
+				return fmt.Errorf("failed to watch %s: %w", dir, err)
+			}
+			return nil
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != w.Root && w.ignoredDir(path) {
+			return filepath.SkipDir
+		}
+
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// relative returns a path relative to Root with forward slashes, as patterns use
+func (w *Watcher) relative(path string) (string, bool) {
+	rel, err := filepath.Rel(w.Root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// matches reports whether a changed file is one the service wants to be restarted for
+func (w *Watcher) matches(rel string) bool {
+	return matchesAny(w.Patterns, rel) && !matchesAny(w.Ignore, rel)
+}
+
+// ignoredDir reports whether a directory can be skipped entirely
+func (w *Watcher) ignoredDir(path string) bool {
+	if skippedDirs[filepath.Base(path)] {
+		return true
+	}
+
+	rel, ok := w.relative(path)
+	if !ok {
+		return true
+	}
+
+	// "vendor/**" ignores everything in vendor, so vendor itself needn't be watched
+	for _, pattern := range w.Ignore {
+		if utils.MatchGlob(pattern, rel) || utils.MatchGlob(strings.TrimSuffix(pattern, "/**"), rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if utils.MatchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package watch
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestMatches checks a change counts when it matches a pattern and no ignore
+// pattern, ignores winning over patterns
+func TestMatches(t *testing.T) {
+	w := &Watcher{
+		Root:     "/app",
+		Patterns: []string{"**/*.go", "config/*.yaml"},
+		Ignore:   []string{"**/*_test.go", "vendor/**", "config/local.yaml"},
+	}
+
+	tests := map[string]bool{
+		"main.go":               true,
+		"cmd/api/main.go":       true,
+		"main_test.go":          false,
+		"pkg/x/x_test.go":       false,
+		"vendor/lib/lib.go":     false,
+		"config/app.yaml":       true,
+		"config/local.yaml":     false,
+		"config/nested/a.yaml":  false,
+		"README.md":             false,
+		"vendored/lib/lib.go":   true,
+		"cmd/vendor/lib/lib.go": true,
+		"web/node_modules/a.go": true,
+	}
+
+	for rel, want := range tests {
+		if got := w.matches(rel); got != want {
+			t.Errorf("matches(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+// TestIgnoredDir checks which directories aren't watched at all
+func TestIgnoredDir(t *testing.T) {
+	root := filepath.FromSlash("/app")
+	w := &Watcher{Root: root, Ignore: []string{"vendor/**", "**/dist/**", "tmp"}}
+
+	tests := map[string]bool{
+		"src":              false,
+		"vendor":           true,
+		"vendor/lib":       true,
+		"web/dist":         true,
+		"tmp":              true,
+		"tmp2":             false,
+		"node_modules":     true,
+		"web/node_modules": true,
+		".git":             true,
+	}
+
+	for rel, want := range tests {
+		if got := w.ignoredDir(filepath.Join(root, filepath.FromSlash(rel))); got != want {
+			t.Errorf("ignoredDir(%q) = %v, want %v", rel, got, want)
+		}
+	}
+
+	if !w.ignoredDir(filepath.FromSlash("/elsewhere")) {
+		t.Error("a directory outside the root should be ignored")
+	}
+}